@@ -0,0 +1,114 @@
+// Package api mirrors the request and response types exposed by the
+// ShadowPrism core engine over its Unix socket.
+package api
+
+import "time"
+
+// TaskStatus is the lifecycle state of a persisted transaction.
+type TaskStatus string
+
+const (
+	StatusPending   TaskStatus = "Pending"
+	StatusBroadcast TaskStatus = "Broadcast"
+	StatusConfirmed TaskStatus = "Confirmed"
+	StatusFailed    TaskStatus = "Failed"
+)
+
+// Terminal reports whether the watchdog will no longer change the status.
+func (s TaskStatus) Terminal() bool {
+	return s == StatusConfirmed || s == StatusFailed
+}
+
+type ShieldRequest struct {
+	AmountLamports  uint64 `json:"amount_lamports"`
+	DestinationAddr string `json:"destination_addr"`
+	Strategy        string `json:"strategy"`
+	Force           bool   `json:"force,omitempty"`
+}
+
+type ShieldResponse struct {
+	TaskID   string  `json:"task_id"`
+	Status   string  `json:"status"`
+	TxHash   string  `json:"tx_hash"`
+	Provider string  `json:"provider"`
+	Note     *string `json:"note"`
+}
+
+type SwapRequest struct {
+	AmountLamports uint64 `json:"amount_lamports"`
+	FromToken      string `json:"from_token"`
+	ToToken        string `json:"to_token"`
+}
+
+type SwapResponse struct {
+	Status     string `json:"status"`
+	TxHash     string `json:"tx_hash"`
+	FromAmount uint64 `json:"from_amount"`
+	ToAmount   uint64 `json:"to_amount"`
+}
+
+type PayRequest struct {
+	MerchantID     string `json:"merchant_id"`
+	AmountLamports uint64 `json:"amount_lamports"`
+}
+
+type PayResponse struct {
+	Status    string `json:"status"`
+	TxHash    string `json:"tx_hash"`
+	ReceiptID string `json:"receipt_id"`
+}
+
+// TransactionRecord is a row from the core's transaction store, as returned
+// by /v1/history and /v1/tasks/{id}.
+type TransactionRecord struct {
+	ID             string     `json:"id"`
+	AmountLamports uint64     `json:"amount_lamports"`
+	Destination    string     `json:"destination"`
+	Status         TaskStatus `json:"status"`
+	TxHash         *string    `json:"tx_hash"`
+	Provider       string     `json:"provider"`
+	Note           *string    `json:"note"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// HistoryQuery filters /v1/history. Zero fields are left out of the query
+// string and match everything; Until is exclusive. The core returns 50 rows
+// when Limit is zero and at most MaxHistoryLimit per page.
+type HistoryQuery struct {
+	Status      TaskStatus
+	Provider    string
+	Destination string
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+	Offset      int
+}
+
+// MaxHistoryLimit is the largest page the core will return.
+const MaxHistoryLimit = 1000
+
+type HealthStatus struct {
+	Status   string `json:"status"`
+	Engine   string `json:"engine"`
+	Protocol string `json:"protocol"`
+}
+
+type MarketData struct {
+	Asset    string  `json:"asset"`
+	PriceUSD float64 `json:"price_usd"`
+	Provider string  `json:"provider"`
+}
+
+// Balance is the SOL balance of the core's wallet.
+type Balance struct {
+	Address  string `json:"address"`
+	Lamports uint64 `json:"lamports"`
+}
+
+// Deref returns the value of an optional string field, or "" when it is null.
+func Deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
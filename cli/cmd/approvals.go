@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/internal/agent"
+	tele "gopkg.in/telebot.v3"
+)
+
+// botApprovalTimeout is how long a proposed action waits for a tap before
+// it counts as rejected.
+const botApprovalTimeout = 2 * time.Minute
+
+// Callback IDs of the approval buttons.
+const (
+	btnToolApprove = "tool_approve"
+	btnToolReject  = "tool_reject"
+)
+
+// approvals routes taps on approval buttons to the chat turn waiting for
+// them. Only the user who asked may answer.
+type approvals struct {
+	mu      sync.Mutex
+	pending map[string]pendingApproval
+}
+
+type pendingApproval struct {
+	chatID int64
+	userID int64
+	answer chan bool
+}
+
+func newApprovals() *approvals {
+	return &approvals{pending: map[string]pendingApproval{}}
+}
+
+func (a *approvals) open(chatID, userID int64) (string, <-chan bool, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	id := hex.EncodeToString(buf)
+	answer := make(chan bool, 1)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending[id] = pendingApproval{chatID: chatID, userID: userID, answer: answer}
+	return id, answer, nil
+}
+
+func (a *approvals) close(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.pending, id)
+}
+
+// answer delivers a tap. It reports false when the approval has expired or
+// belongs to someone else.
+func (a *approvals) answer(id string, chatID, userID int64, ok bool) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p, found := a.pending[id]
+	if !found || p.chatID != chatID || p.userID != userID {
+		return false
+	}
+	delete(a.pending, id)
+	p.answer <- ok
+	return true
+}
+
+// handle registers the approve and reject buttons on b.
+func (a *approvals) handle(b *tele.Bot) {
+	respond := func(ok bool) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			if !a.answer(c.Data(), c.Chat().ID, c.Sender().ID, ok) {
+				return c.Respond(&tele.CallbackResponse{Text: "This request is no longer yours to answer."})
+			}
+			verdict := "✅ Approved"
+			if !ok {
+				verdict = "❌ Rejected"
+			}
+			c.Respond(&tele.CallbackResponse{Text: verdict})
+			return c.Edit(c.Message().Text + "\n\n" + verdict)
+		}
+	}
+	b.Handle(&tele.Btn{Unique: btnToolApprove}, respond(true))
+	b.Handle(&tele.Btn{Unique: btnToolReject}, respond(false))
+}
+
+// telegramOperator asks the sender of a chat message to approve the actions
+// the assistant proposes while answering it.
+type telegramOperator struct {
+	bot       *tele.Bot
+	chat      *tele.Chat
+	userID    int64
+	approvals *approvals
+}
+
+func (o *telegramOperator) Confirm(ctx context.Context, action *agent.Action) bool {
+	id, answer, err := o.approvals.open(o.chat.ID, o.userID)
+	if err != nil {
+		return false
+	}
+	defer o.approvals.close(id)
+
+	markup := &tele.ReplyMarkup{}
+	markup.Inline(markup.Row(
+		markup.Data("✅ Approve", btnToolApprove, id),
+		markup.Data("❌ Reject", btnToolReject, id),
+	))
+	// Plain text: summaries and previews are full of underscores.
+	text := fmt.Sprintf("📝 Proposed: %s\n\n%s", action.Summary, preview(action))
+	msg, err := o.bot.Send(o.chat, text, markup)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to ask chat %d for approval: %v\n", o.chat.ID, err)
+		return false
+	}
+
+	timer := time.NewTimer(botApprovalTimeout)
+	defer timer.Stop()
+	select {
+	case ok := <-answer:
+		return ok
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	o.bot.Edit(msg, text+"\n\n⌛ Expired, nothing was sent.")
+	return false
+}
+
+func (o *telegramOperator) Done(action *agent.Action, result any, err error) {
+	if !action.MovesValue {
+		return
+	}
+	if err != nil {
+		o.bot.Send(o.chat, friendlyError(action.Summary+" failed", err))
+		return
+	}
+	msg := "✅ " + action.Summary + ": sent."
+	if action.Private != "" {
+		msg += "\n🧾 " + action.Private
+	}
+	o.bot.Send(o.chat, msg)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/internal/agent"
+	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
+)
+
+// aiKeySecret holds the API key for agent.base_url. OPENAI_API_KEY is used
+// when it isn't stored.
+const aiKeySecret = "AI_API_KEY"
+
+// newAgent builds the assistant selected by the agent.* settings.
+func newAgent() (*agent.PrismAgent, error) {
+	cfg := agent.Config{
+		Backend:  settings.Get("agent.backend"),
+		VibePath: settings.Get("agent.vibeaura_path"),
+		BaseURL:  settings.Get("agent.base_url"),
+		Model:    settings.Get("agent.model"),
+		APIKey:   os.Getenv("OPENAI_API_KEY"),
+		Privacy:  settings.Get("agent.privacy"),
+	}
+
+	// Only open the secret store when the key can be used, so vibeaura
+	// users never get a passphrase prompt for it.
+	if cfg.BaseURL != "" {
+		cm, err := configManager()
+		if err != nil {
+			return nil, err
+		}
+		if cm.SecretExists(aiKeySecret) {
+			if cfg.APIKey, err = cm.LoadSecret(aiKeySecret); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	a, err := agent.New(cfg)
+	if err != nil {
+		return nil, usageError(err)
+	}
+	return agent.NewPrismAgent(a), nil
+}
+
+// agentComment prints the assistant's take on what a command just did. It
+// is commentary only, so a missing or failing backend prints nothing.
+func agentComment(ctx context.Context, prompt string) {
+	pa, err := newAgent()
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	pa.DisplayStream(pa.TalkStream(ctx, prompt))
+}
+
+// toolTurnTimeout bounds a chat turn that may call tools. It is long because
+// it includes the time the user takes to approve an action.
+const toolTurnTimeout = 5 * time.Minute
+
+// agentTools is the set of core operations the assistant may call. Aliases
+// resolve through the active profile's address book.
+func agentTools(core agent.CoreFunc) ([]agent.Tool, error) {
+	cm, err := configManager()
+	if err != nil {
+		return nil, err
+	}
+	book, err := cm.LoadAddressBook()
+	if err != nil {
+		return nil, err
+	}
+	return agent.CoreTools(core, book, settings.Get("shield.strategy")), nil
+}
+
+// lazyCore connects to the core the first time a tool needs it, so a chat
+// that only talks never starts one.
+type lazyCore struct {
+	mu   sync.Mutex
+	sess *sidecar.Session
+}
+
+func (l *lazyCore) client(ctx context.Context) (*sidecar.CoreClient, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sess == nil {
+		sess, err := connectCore(ctx)
+		if err != nil {
+			return nil, err
+		}
+		l.sess = sess
+	}
+	return l.sess.Client, nil
+}
+
+func (l *lazyCore) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.sess == nil {
+		return nil
+	}
+	return l.sess.Close()
+}
+
+// preview renders the exact request an action will send.
+func preview(action *agent.Action) string {
+	data, err := json.MarshalIndent(action.Request, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
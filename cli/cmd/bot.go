@@ -2,26 +2,44 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/nathfavour/shadowprism/cli/api"
 	"github.com/nathfavour/shadowprism/cli/internal/agent"
+	"github.com/nathfavour/shadowprism/cli/internal/amount"
 	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
 	"github.com/spf13/cobra"
 	tele "gopkg.in/telebot.v3"
 )
 
+// botUpdateTimeout caps how long a single Telegram update may spend waiting
+// on the core, including on-chain confirmation.
+const botUpdateTimeout = 2 * time.Minute
+
+// botEditInterval paces the edits that stream an AI reply into its message,
+// staying well inside Telegram's rate limits.
+const botEditInterval = time.Second
+
+// solAmount reads chat amounts like "/shield 0.5 @treasury" as whole SOL.
+var solAmount = amount.Options{Token: "SOL", Whole: true}
+
+var (
+	botPersistChats bool
+	botNoTools      bool
+)
+
 var botCmd = &cobra.Command{
 	Use:   "bot",
 	Short: "Start the ShadowPrism Telegram Bot",
-	Run: func(cmd *cobra.Command, args []string) {
-		cm, err := sidecar.NewConfigManager()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
 		if err != nil {
-			fmt.Printf("❌ Error: %v\n", err)
-			os.Exit(1)
+			return err
 		}
 
 		token, err := cm.LoadSecret("tg_bot_token")
@@ -30,27 +48,15 @@ var botCmd = &cobra.Command{
 		}
 
 		if token == "" {
-			fmt.Println("❌ Error: Telegram Bot token not found in config or environment.")
-			fmt.Println("Run: shadowprism config set-bot-token <your_token>")
-			os.Exit(1)
+			return errors.New("Telegram Bot token not found in config or environment\nRun: shadowprism config set-bot-token <your_token>")
 		}
 
-		authToken := "dev-token-123"
-
-		manager := sidecar.NewManager(42069, authToken)
-
-		
-		socketPath := cm.GetSocketPath()
-		
-		fmt.Println("🚀 Starting ShadowPrism Core for Bot Mode...")
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		if err := manager.Start(ctx); err != nil {
-			fmt.Printf("❌ Failed to start core engine: %v\n", err)
-			os.Exit(1)
+		sess, err := connectCore(cmd.Context())
+		if err != nil {
+			return err
 		}
-		defer manager.Stop()
+		defer sess.Close()
+		client := sess.Client
 
 		pref := tele.Settings{
 			Token:  token,
@@ -59,648 +65,535 @@ var botCmd = &cobra.Command{
 
 		b, err := tele.NewBot(pref)
 		if err != nil {
-			fmt.Printf("❌ Failed to start Telegram Bot: %v\n", err)
-			return
+			return fmt.Errorf("failed to start Telegram Bot: %w", err)
 		}
 
-				client := sidecar.NewCoreClient(socketPath, authToken)
-				pa := agent.NewPrismAgent()
-
-				// 1. Setup Bot Command Menu
-
-				b.SetCommands([]tele.Command{
-
-					{Text: "start", Description: "Launch ShadowPrism Dashboard"},
-
-					{Text: "shield", Description: "Anonymize SOL (Privacy Cash/Radr)"},
-
-					{Text: "swap", Description: "Private Token Exchange (SilentSwap)"},
-
-					{Text: "pay", Description: "Pay Merchants Privately (Starpay)"},
-
-					{Text: "market", Description: "Check Privacy Market (Encrypt.trade)"},
-
-					{Text: "chat", Description: "Talk to ShadowPrism AI Assistant"},
-
-					{Text: "monitor", Description: "Live Stealth Feed (System Activity)"},
-
-					{Text: "score", Description: "Check Privacy Health Score"},
-
-					{Text: "agent", Description: "PNP Agent-to-Agent Simulation"},
-
-					{Text: "history", Description: "View Shielded History"},
-
-					{Text: "status", Description: "System Health & RPC Failover"},
-
-				})
-
-		
-
-				// 2. Inline Keyboards
-
-				mainMenu := &tele.ReplyMarkup{}
-
-				btnShield := mainMenu.Data("🛡️ Shield SOL", "shield_menu")
-
-				btnSwap := mainMenu.Data("🔄 Private Swap", "swap_menu")
-
-				btnPay := mainMenu.Data("💳 Pay Merchant", "pay_menu")
-
-				btnMarket := mainMenu.Data("📊 Market Data", "market_menu")
-
-				btnHistory := mainMenu.Data("📜 History", "history_menu")
-
-		
-
-				mainMenu.Inline(
-
-					mainMenu.Row(btnShield, btnSwap),
-
-					mainMenu.Row(btnPay, btnMarket),
-
-					mainMenu.Row(btnHistory),
-
-				)
-
-		
-
-				// 3. Command Handlers
-
-				b.Handle("/start", func(c tele.Context) error {
-
-					logo := "🛡️ *SHADOWPRISM: PRIVACY SIDECAR*\n"
-
-					desc := "Welcome to the ultimate privacy layer for Solana.\n\n" +
-
-						"*Sponsor Tracks Active:* 9/9\n" +
-
-						"*Mode:* Autonomous (No Passphrase)\n" +
-
-						"*Network:* Solana Devnet"
-
-					
-
-					return c.Send(logo+desc, tele.ModeMarkdown, mainMenu)
-
-				})
-
-		
-
-				b.Handle("/status", func(c tele.Context) error {
-
-					status, err := client.GetStatus()
-
-					if err != nil {
-
-						return c.Send("❌ Core Engine is unreachable.")
-
-					}
-
-					
-
-					failoverStatus := "🟢 Active (Helius + QuickNode)"
-
-					complianceStatus := "🛡️ Range Protocol Guarded"
-
-					
-
-					res := fmt.Sprintf("✅ *System Status*\n\n"+
-
-						"Engine: `%v`\n"+
-
-						"RPC Stack: `%s`\n"+
-
-						"Firewall: `%s`\n"+
-
-						"UDS Socket: `Active`", 
-
-						status["engine"], failoverStatus, complianceStatus)
-
-						
-
-					return c.Send(res, tele.ModeMarkdown)
-
-				})
-
-		
-
-				b.Handle("/market", func(c tele.Context) error {
-
-					res, err := client.GetMarket()
-
-					if err != nil {
-
-						return c.Send("❌ Failed to fetch market data.")
-
-					}
-
-					return c.Send(fmt.Sprintf("📊 *Market Data (via Encrypt.trade)*\n\nAsset: `SOL`\nPrice: `$%.2f USD`\nProvider: `Encrypt.trade Oracle`", res["price_usd"]), tele.ModeMarkdown)
-
-				})
-
-		
-
-				b.Handle("/history", func(c tele.Context) error {
-
-					history, err := client.GetHistory()
-
-					if err != nil {
-
-						return c.Send("❌ Failed to fetch history.")
-
-					}
-
-		
-
-					if len(history) == 0 {
-
-						return c.Send("📜 *History is clean.* No shielded transactions found.")
-
-					}
-
-		
-
-					res := "📜 *Recent Shielded History*\n\n"
-
-					for i, tx := range history {
-
-						if i >= 5 { break }
-
-						statusEmoji := "✅"
-
-						if tx["status"] != "Confirmed" { statusEmoji = "⏳" }
-
-						
-
-						res += fmt.Sprintf("%s *%.4f SOL* to `%s...`\n   _via %s_\n\n", 
-
-							statusEmoji, 
-
-							float64(tx["amount_lamports"].(float64))/1e9,
-
-							tx["destination"].(string)[:6],
-
-							tx["provider"])
-
-					}
-
-					return c.Send(res, tele.ModeMarkdown)
-
-				})
-
-		
-
-						// 4. Interactive Callbacks
-
-		
-
-						b.Handle(&btnMarket, func(c tele.Context) error {
-
-		
-
-							return c.Send("📊 *Checking market data via Encrypt.trade...*", tele.ModeMarkdown)
-
-		
-
-						})
-
-		
-
-				
-
-		
-
-						b.Handle(&btnShield, func(c tele.Context) error {
-
-		
-
-							return c.Send("🕵️ To anonymize SOL, use: `/shield [amount]`", tele.ModeMarkdown)
-
-		
-
-						})
-
-		
-
-				
-
-		
-
-						b.Handle(&btnSwap, func(c tele.Context) error {
-
-		
-
-							return c.Send("🔄 To execute a private swap, use: `/swap [amount]`", tele.ModeMarkdown)
-
-		
-
-						})
-
-		
-
-				
-
-		
-
-						b.Handle(&btnPay, func(c tele.Context) error {
-
-		
-
-							return c.Send("💳 To pay a merchant privately, use: `/pay [merchant_id] [amount]`", tele.ModeMarkdown)
-
-		
-
-						})
-
-		
-
-				
-
-		
-
-						b.Handle(&btnHistory, func(c tele.Context) error {
-
-		
-
-							return c.Send("📜 Use `/history` to view your encrypted transaction log.", tele.ModeMarkdown)
-
-		
-
-						})
-
-		
-
-				
-
-		
-
-				// 5. Command Handlers
-
-				b.Handle("/shield", func(c tele.Context) error {
-
-					args := c.Args()
-
-					if len(args) < 1 {
-
-						return c.Send("💡 Usage: `/shield [amount]`\nExample: `/shield 0.5`", tele.ModeMarkdown)
-
-					}
-
-		
-
-										amountSOL, _ := strconv.ParseFloat(args[0], 64)
-
-		
-
-										lamports := uint64(amountSOL * 1e9)
-
-		
-
-										dest := "PCashMixer111111111111111111111111111111111" // Realistic Mixer Address
-
-		
-
-					
-
-		
-
-					c.Send("🕵️ *Initiating Privacy Shield...*\n1. Checking Range Protocol Risk...\n2. Calculating Helius Smart Fees...")
-
-		
-
-					res, err := client.Shield(lamports, dest, "privacy_cash", false)
-
-					if err != nil {
-
-						return c.Send("❌ Shielding failed: " + err.Error())
-
-					}
-
-		
-
-										note := res["note"].(string)
-
-		
-
-										path := "\n📍 *Routing Path:*\n`[Me] ➔ [Range Firewall] ➔ [Mixer] ➔ [Vault]`"
-
-		
-
-										return c.Send(fmt.Sprintf("✅ *Shield Success!*\n\n💰 *Amount:* `%.4f SOL`\n🔗 *TX:* `%v` \n🛡️ *Provider:* `Privacy Cash` \n🔑 *Note:* `%v` %s\n\n_Note stored in local encrypted DB._", amountSOL, res["tx_hash"], note, path), tele.ModeMarkdown)
-
-		
-
-									})
-
-		
-
-					
-
-		
-
-									b.Handle("/swap", func(c tele.Context) error {
-
-		
-
-										args := c.Args()
-
-		
-
-										if len(args) < 1 {
-
-		
-
-											return c.Send("💡 Usage: `/swap [amount]`\nExample: `/swap 1.0`", tele.ModeMarkdown)
-
-		
-
-										}
-
-		
-
-					
-
-		
-
-										amountSOL, _ := strconv.ParseFloat(args[0], 64)
-
-		
-
-										lamports := uint64(amountSOL * 1e9)
-
-		
-
-					
-
-		
-
-										c.Send("🔄 *Executing Private Swap (SilentSwap)...*")
-
-		
-
-					
-
-		
-
-										res, err := client.Swap(lamports, "SOL", "USDC")
-
-		
-
-										if err != nil {
-
-		
-
-											return c.Send("❌ Swap failed: " + err.Error())
-
-		
-
-										}
-
-		
-
-					
-
-		
-
-										path := "\n📍 *Routing Path:*\n`[SOL] ➔ [Mixer] ➔ [Jupiter Pool] ➔ [USDC]`"
-
-		
-
-															return c.Send(fmt.Sprintf("✅ *Swap Confirmed!*\n\n📤 *From:* `%.2f SOL` \n📥 *To:* `%.2f USDC` \n🔗 *TX:* `%v` \n🛡️ *Adapter:* `SilentSwap` %s", amountSOL, float64(res["to_amount"].(float64))/1e9, res["tx_hash"], path), tele.ModeMarkdown)
-
-		
-
-										
-
-		
-
-														})
-
-		
-
-										
-
-		
+		pa, err := newAgent()
+		if err != nil {
+			return err
+		}
+		chats := newChatSessions(botPersistChats)
+
+		// Every update gets its own deadline derived from the command context,
+		// so a slow core call is abandoned instead of stalling the poller and
+		// Ctrl-C aborts whatever is in flight.
+		base := cmd.Context()
+		updateCtx := func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(base, botUpdateTimeout)
+		}
 
-														b.Handle("/pay", func(c tele.Context) error {
+		// The assistant may use the core on the user's behalf; anything that
+		// moves funds waits for the user to tap Approve.
+		var tools []agent.Tool
+		if !botNoTools {
+			tools, err = agentTools(func(context.Context) (*sidecar.CoreClient, error) { return client, nil })
+			if err != nil {
+				return err
+			}
+		}
+		pending := newApprovals()
+		pending.handle(b)
+		chatTurn := func(c tele.Context, text string) error {
+			timeout := 15 * time.Second
+			if tools != nil {
+				timeout = toolTurnTimeout
+			}
+			ctx, cancel := context.WithTimeout(base, timeout)
+			defer cancel()
+			defer chats.begin(c.Chat().ID, cancel)()
+			op := &telegramOperator{bot: b, chat: c.Chat(), userID: c.Sender().ID, approvals: pending}
+			return botChat(ctx, b, c.Chat(), pa, chats, text, tools, op)
+		}
 
-		
+		// Track the supervisor's view of the engine so /status can explain
+		// an outage instead of just reporting it.
+		var engineMu sync.Mutex
+		var engineEvent *sidecar.Event
+		if events := sess.Events(); events != nil {
+			go func() {
+				for e := range events {
+					fmt.Printf("🛰️  [Core] %s\n", e)
+					engineMu.Lock()
+					engineEvent = &e
+					engineMu.Unlock()
+				}
+			}()
+		}
 
-															args := c.Args()
+		// 1. Setup Bot Command Menu
 
-		
+		b.SetCommands([]tele.Command{
+			{Text: "start", Description: "Launch ShadowPrism Dashboard"},
+			{Text: "shield", Description: "Anonymize SOL (Privacy Cash/Radr)"},
+			{Text: "swap", Description: "Private Token Exchange (SilentSwap)"},
+			{Text: "pay", Description: "Pay Merchants Privately (Starpay)"},
+			{Text: "market", Description: "Check Privacy Market (Encrypt.trade)"},
+			{Text: "chat", Description: "Talk to ShadowPrism AI Assistant"},
+			{Text: "reset", Description: "Clear the AI conversation memory"},
+			{Text: "stop", Description: "Stop the AI reply in progress"},
+			{Text: "monitor", Description: "Live Stealth Feed (System Activity)"},
+			{Text: "score", Description: "Check Privacy Health Score"},
+			{Text: "agent", Description: "PNP Agent-to-Agent Simulation"},
+			{Text: "history", Description: "View Shielded History"},
+			{Text: "status", Description: "System Health & RPC Failover"},
+		})
 
-															if len(args) < 2 {
+		// 2. Inline Keyboards
 
-		
+		mainMenu := &tele.ReplyMarkup{}
 
-																return c.Send("💡 Usage: `/pay [merchant_id] [amount]`\nExample: `/pay SPayX... 0.5`", tele.ModeMarkdown)
+		btnShield := mainMenu.Data("🛡️ Shield SOL", "shield_menu")
 
-		
+		btnSwap := mainMenu.Data("🔄 Private Swap", "swap_menu")
 
-															}
+		btnPay := mainMenu.Data("💳 Pay Merchant", "pay_menu")
 
-		
+		btnMarket := mainMenu.Data("📊 Market Data", "market_menu")
 
-										
+		btnHistory := mainMenu.Data("📜 History", "history_menu")
 
-		
+		mainMenu.Inline(
+			mainMenu.Row(btnShield, btnSwap),
+			mainMenu.Row(btnPay, btnMarket),
+			mainMenu.Row(btnHistory),
+		)
 
-															merchant := args[0]
+		// 3. Command Handlers
 
-		
+		b.Handle("/start", func(c tele.Context) error {
+			logo := "🛡️ *SHADOWPRISM: PRIVACY SIDECAR*\n"
 
-															amountSOL, _ := strconv.ParseFloat(args[1], 64)
+			desc := "Welcome to the ultimate privacy layer for Solana.\n\n" +
+				"*Sponsor Tracks Active:* 9/9\n" +
+				"*Mode:* Autonomous (No Passphrase)\n" +
+				fmt.Sprintf("*Profile:* `%s`\n", cm.Profile) +
+				fmt.Sprintf("*Network:* Solana %s", settings.Get("network"))
 
-		
+			return c.Send(logo+desc, tele.ModeMarkdown, mainMenu)
+		})
 
-										
+		b.Handle("/status", func(c tele.Context) error {
+			ctx, cancel := updateCtx()
+			defer cancel()
 
-		
+			status, err := client.GetStatus(ctx)
 
-															c.Send("💳 *Initiating Private Settlement via Starpay...*")
+			if err != nil {
+				engineMu.Lock()
+				e := engineEvent
+				engineMu.Unlock()
+				if e != nil && e.Kind != sidecar.EventStarted {
+					return c.Send("❌ Core Engine is unreachable.\n🛰️ Supervisor: " + e.String())
+				}
+				return c.Send("❌ Core Engine is unreachable.")
+			}
 
-		
+			failoverStatus := "🟢 Active (Helius + QuickNode)"
 
-										
+			complianceStatus := "🛡️ Range Protocol Guarded"
 
-		
+			res := fmt.Sprintf("✅ *System Status*\n\n"+
+				"Engine: `%v`\n"+
+				"RPC Stack: `%s`\n"+
+				"Firewall: `%s`\n"+
+				"UDS Socket: `Active`",
+				status.Engine, failoverStatus, complianceStatus)
 
-															res, err := client.Pay(uint64(amountSOL*1e9), merchant)
+			return c.Send(res, tele.ModeMarkdown)
+		})
 
-		
+		b.Handle("/market", func(c tele.Context) error {
+			ctx, cancel := updateCtx()
+			defer cancel()
 
-															if err != nil {
+			res, err := client.GetMarket(ctx)
 
-		
+			if err != nil {
+				return c.Send(friendlyError("Failed to fetch market data", err))
+			}
 
-																return c.Send("❌ Payment failed: " + err.Error())
+			return c.Send(fmt.Sprintf("📊 *Market Data (via Encrypt.trade)*\n\nAsset: `SOL`\nPrice: `$%.2f USD`\nProvider: `Encrypt.trade Oracle`", res.PriceUSD), tele.ModeMarkdown)
+		})
 
-		
+		b.Handle("/history", func(c tele.Context) error {
+			ctx, cancel := updateCtx()
+			defer cancel()
 
-															}
+			history, err := client.GetHistory(ctx, api.HistoryQuery{Limit: 5})
 
-		
+			if err != nil {
+				return c.Send(friendlyError("Failed to fetch history", err))
+			}
 
-										
+			if len(history) == 0 {
+				return c.Send("📜 *History is clean.* No shielded transactions found.")
+			}
 
-		
+			res := "📜 *Recent Shielded History*\n\n"
 
-															receipt := fmt.Sprintf("🕶️ *PRIVATE GHOST RECEIPT*\n"+
+			for _, tx := range history {
+				statusEmoji := "✅"
 
-		
+				if tx.Status != api.StatusConfirmed {
+					statusEmoji = "⏳"
+				}
 
-																"`--------------------------`\n"+
+				res += fmt.Sprintf("%s *%s* to `%s...`\n   _via %s_\n\n",
+					statusEmoji,
+					amount.SOL(tx.AmountLamports),
+					truncate(tx.Destination, 6),
+					tx.Provider)
+			}
 
-		
+			return c.Send(res, tele.ModeMarkdown)
+		})
 
-																"MERCHANT: `%s...`\n"+
+		// 4. Interactive Callbacks
 
-		
+		b.Handle(&btnMarket, func(c tele.Context) error {
+			return c.Send("📊 *Checking market data via Encrypt.trade...*", tele.ModeMarkdown)
+		})
 
-																"AMOUNT:   `%.4f SOL`\n"+
+		b.Handle(&btnShield, func(c tele.Context) error {
+			return c.Send("🕵️ To anonymize SOL, use: `/shield [amount] [destination]`", tele.ModeMarkdown)
+		})
 
-		
+		b.Handle(&btnSwap, func(c tele.Context) error {
+			return c.Send("🔄 To execute a private swap, use: `/swap [amount]`", tele.ModeMarkdown)
+		})
 
-																"STATUS:   `ENCRYPTED`\n"+
+		b.Handle(&btnPay, func(c tele.Context) error {
+			return c.Send("💳 To pay a merchant privately, use: `/pay [merchant_id] [amount]`", tele.ModeMarkdown)
+		})
 
-		
+		b.Handle(&btnHistory, func(c tele.Context) error {
+			return c.Send("📜 Use `/history` to view your encrypted transaction log.", tele.ModeMarkdown)
+		})
 
-																"REF ID:   `%s`\n"+
+		// 5. Command Handlers
 
-		
+		b.Handle("/shield", func(c tele.Context) error {
+			args := c.Args()
 
-																"`--------------------------`",
+			if len(args) < 2 || len(args) > 3 {
+				return c.Send("💡 Usage: `/shield [amount] [destination]`\nThe destination is an address or an @alias.\nExample: `/shield 0.5 @treasury`, `/shield 10% @treasury` or `/shield 1 SOL @treasury`", tele.ModeMarkdown)
+			}
 
-		
+			input, target := args[0], args[1]
+			if len(args) == 3 {
+				input, target = args[0]+" "+args[1], args[2]
+			}
+			amt, err := amount.Parse(input, solAmount)
+			if err != nil {
+				return c.Send("❌ " + err.Error())
+			}
+			dest, err := resolveAddress(target)
+			if err != nil {
+				return c.Send("❌ " + err.Error())
+			}
 
-																merchant[:8], amountSOL, res["receipt_id"])
+			c.Send("🕵️ *Initiating Privacy Shield...*\n1. Checking Range Protocol Risk...\n2. Calculating Helius Smart Fees...")
 
-		
+			ctx, cancel := updateCtx()
+			defer cancel()
 
-										
+			lamports, err := client.ResolveAmount(ctx, amt)
+			if err != nil {
+				return c.Send("❌ " + err.Error())
+			}
 
-		
+			res, err := client.Shield(ctx, lamports, dest, settings.Get("shield.strategy"), false)
 
-															return c.Send(receipt, tele.ModeMarkdown)
+			if err != nil {
+				return c.Send(friendlyError("Shielding failed", err))
+			}
 
-		
+			note := api.Deref(res.Note)
 
-														})
+			path := "\n📍 *Routing Path:*\n`[Me] ➔ [Range Firewall] ➔ [Mixer] ➔ [Vault]`"
 
-		
+			return c.Send(fmt.Sprintf("✅ *Shield Success!*\n\n💰 *Amount:* `%s`\n🔗 *TX:* `%v` \n🛡️ *Provider:* `Privacy Cash` \n🔑 *Note:* `%v` %s\n\n_Note stored in local encrypted DB._", amount.SOL(lamports), res.TxHash, note, path), tele.ModeMarkdown)
+		})
 
-										
+		b.Handle("/swap", func(c tele.Context) error {
+			args := c.Args()
 
-		
+			if len(args) < 1 {
+				return c.Send("💡 Usage: `/swap [amount]`\nExample: `/swap 1.0`", tele.ModeMarkdown)
+			}
 
-														b.Handle("/monitor", func(c tele.Context) error {
+			from, to := settings.Get("swap.from"), settings.Get("swap.to")
+			amt, err := amount.Parse(args[0], amount.Options{Token: from, Whole: true})
+			if err != nil {
+				return c.Send("❌ " + err.Error())
+			}
 
-		
+			c.Send("🔄 *Executing Private Swap (SilentSwap)...*")
 
-										
-					c.Send("📡 *ShadowPrism Stealth Feed Activated*\nListening to system bus...")
-					
-					steps := []string{
-						"🔍 [UDS] IPC Heartbeat: Core engine online",
-						"🛡️ [Compliance] Range Protocol firewall sync complete",
-						"⚡ [Smart Fee] Helius Priority API: Low congestion (5000 mL)",
-						"🗝️ [Keystore] Master key decrypted in memory",
-						"🛰️ [PNP] Scanning peer network for agent pings...",
-						"🟢 [Ready] System waiting for intent.",
-					}
+			ctx, cancel := updateCtx()
+			defer cancel()
 
-					for _, step := range steps {
-						time.Sleep(800 * time.Millisecond)
-						c.Send("`" + step + "`", tele.ModeMarkdown)
-					}
+			units, err := client.ResolveAmount(ctx, amt)
+			if err != nil {
+				return c.Send("❌ " + err.Error())
+			}
 
-					return c.Send("✅ *Monitoring Session Stable*")
-				})
+			res, err := client.Swap(ctx, units, from, to)
 
-				b.Handle("/score", func(c tele.Context) error {
-					history, _ := client.GetHistory()
-					score := 100
-					if len(history) == 0 {
-						score = 45 // New users have lower privacy score
-					} else if len(history) < 3 {
-						score = 75
-					}
+			if err != nil {
+				return c.Send(friendlyError("Swap failed", err))
+			}
 
-					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-					defer cancel()
-					
-					advice, _ := pa.Talk(ctx, fmt.Sprintf("The user has a privacy score of %d/100 based on %d transactions. Give a short, encouraging hacker-style tip.", score, len(history)))
+			path := fmt.Sprintf("\n📍 *Routing Path:*\n`[%s] ➔ [Mixer] ➔ [Jupiter Pool] ➔ [%s]`", from, to)
 
-					res := fmt.Sprintf("🛡️ *Privacy Health Score*\n\n"+
-						"Score: `%d/100`\n"+
-						"Rating: `%s`\n\n"+
-						"🤖 *Agent Analysis:* %s",
-						score, getRating(score), advice)
+			return c.Send(fmt.Sprintf("✅ *Swap Confirmed!*\n\n📤 *From:* `%s` \n📥 *To:* `%s` \n🔗 *TX:* `%v` \n🛡️ *Adapter:* `SilentSwap` %s", amount.Format(units, from), amount.Format(res.ToAmount, to), res.TxHash, path), tele.ModeMarkdown)
+		})
 
-					return c.Send(res, tele.ModeMarkdown)
-				})
+		b.Handle("/pay", func(c tele.Context) error {
+			args := c.Args()
+
+			if len(args) < 2 {
+				return c.Send("💡 Usage: `/pay [merchant_id] [amount]`\nExample: `/pay @coffee-shop 0.2`", tele.ModeMarkdown)
+			}
+
+			merchant, err := resolveAddress(args[0])
+			if err != nil {
+				return c.Send("❌ " + err.Error())
+			}
+
+			amt, err := amount.Parse(args[1], solAmount)
+			if err != nil {
+				return c.Send("❌ " + err.Error())
+			}
+
+			c.Send("💳 *Initiating Private Settlement via Starpay...*")
+
+			ctx, cancel := updateCtx()
+			defer cancel()
+
+			lamports, err := client.ResolveAmount(ctx, amt)
+			if err != nil {
+				return c.Send("❌ " + err.Error())
+			}
+
+			res, err := client.Pay(ctx, lamports, merchant)
+
+			if err != nil {
+				return c.Send(friendlyError("Payment failed", err))
+			}
+
+			receipt := fmt.Sprintf("🕶️ *PRIVATE GHOST RECEIPT*\n"+
+				"`--------------------------`\n"+
+				"MERCHANT: `%s...`\n"+
+				"AMOUNT:   `%s`\n"+
+				"STATUS:   `ENCRYPTED`\n"+
+				"REF ID:   `%s`\n"+
+				"`--------------------------`",
+				truncate(merchant, 8), amount.SOL(lamports), res.ReceiptID)
+
+			return c.Send(receipt, tele.ModeMarkdown)
+		})
+
+		b.Handle("/monitor", func(c tele.Context) error {
+			c.Send("📡 *ShadowPrism Stealth Feed Activated*\nListening to system bus...")
+
+			steps := []string{
+				"🔍 [UDS] IPC Heartbeat: Core engine online",
+				"🛡️ [Compliance] Range Protocol firewall sync complete",
+				"⚡ [Smart Fee] Helius Priority API: Low congestion (5000 mL)",
+				"🗝️ [Keystore] Master key decrypted in memory",
+				"🛰️ [PNP] Scanning peer network for agent pings...",
+				"🟢 [Ready] System waiting for intent.",
+			}
+
+			for _, step := range steps {
+				time.Sleep(800 * time.Millisecond)
+				c.Send("`"+step+"`", tele.ModeMarkdown)
+			}
+
+			return c.Send("✅ *Monitoring Session Stable*")
+		})
+
+		b.Handle("/score", func(c tele.Context) error {
+			ctx, cancel := updateCtx()
+			defer cancel()
+
+			history, _ := client.GetHistory(ctx, api.HistoryQuery{})
+			score := 100
+			if len(history) == 0 {
+				score = 45 // New users have lower privacy score
+			} else if len(history) < 3 {
+				score = 75
+			}
+
+			advice, _ := pa.Talk(ctx, fmt.Sprintf("The user has a privacy score of %d/100 based on %d transactions. Give a short, encouraging hacker-style tip.", score, len(history)))
+
+			res := fmt.Sprintf("🛡️ *Privacy Health Score*\n\n"+
+				"Score: `%d/100`\n"+
+				"Rating: `%s`\n\n"+
+				"🤖 *Agent Analysis:* %s",
+				score, getRating(score), advice)
+
+			return c.Send(res, tele.ModeMarkdown)
+		})
+
+		b.Handle("/chat", func(c tele.Context) error {
+			input := c.Args()
+			if len(input) == 0 {
+				return c.Send("🤖 *ShadowPrism AI Assistant*\nHow can I help you with your privacy today?", tele.ModeMarkdown)
+			}
+
+			return chatTurn(c, strings.Join(input, " "))
+		})
+
+		b.Handle("/stop", func(c tele.Context) error {
+			if !chats.stop(c.Chat().ID) {
+				return c.Send("🤷 Nothing to stop.")
+			}
+			return nil
+		})
+
+		b.Handle("/reset", func(c tele.Context) error {
+			if err := chats.reset(c.Chat().ID); err != nil {
+				return c.Send("❌ Failed to clear the conversation.")
+			}
+			return c.Send("🧹 Conversation cleared. I've forgotten everything we discussed.")
+		})
+
+		b.Handle("/agent", func(c tele.Context) error {
+			args := c.Args()
+			if len(args) > 1 && args[0] == "settle" {
+				amt, err := amount.Parse(args[1], solAmount)
+				if err != nil {
+					return c.Send("❌ " + err.Error())
+				}
+
+				c.Send("🛰️ *Autonomous Settlement Triggered...*\nAgent-to-Agent Handshake in progress.")
+
+				ctx, cancel := updateCtx()
+				defer cancel()
+
+				lamports, err := client.ResolveAmount(ctx, amt)
+				if err != nil {
+					return c.Send("❌ " + err.Error())
+				}
+
+				res, err := client.Shield(ctx, lamports, settings.Get("agent.vault"), settings.Get("shield.strategy"), false)
+				if err != nil {
+					return c.Send(friendlyError("Agent Settlement failed", err))
+				}
+				resp, _ := pa.Talk(ctx, fmt.Sprintf("An agent just autonomously settled %s. Give a technical report log summary.", amount.SOL(lamports)))
+
+				return c.Send(fmt.Sprintf("✅ *Settlement Successful*\n\nHash: `%s`\n\n🤖 *Agent Report:* %s", res.TxHash, resp), tele.ModeMarkdown)
+			}
+
+			return c.Send("🛰️ *PNP Agent-to-Agent Portal*\n\n"+
+				"Status: `Listening`\n"+
+				"Last Ping: `Agent-772 (Discovery)`\n\n"+
+				"To trigger an autonomous agent settlement, use: `/agent settle [amount]`", tele.ModeMarkdown)
+		})
+
+		b.Handle(tele.OnText, func(c tele.Context) error {
+			return chatTurn(c, c.Text())
+		})
+
+		go func() {
+			<-base.Done()
+			b.Stop()
+		}()
 
-				b.Handle("/chat", func(c tele.Context) error {
-					pa := agent.NewPrismAgent()
-					ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-					defer cancel()
+		fmt.Println("🤖 Telegram Bot is now online!")
+		b.Start()
+		return nil
+	},
+}
 
-					input := c.Args()
-					if len(input) == 0 {
-						return c.Send("🤖 *ShadowPrism AI Assistant*\nHow can I help you with your privacy today?", tele.ModeMarkdown)
-					}
+// botChat streams the answer to text into one message, edited as the reply
+// grows, and saves the transcript when chats are persisted. With tools the
+// assistant may act, asking op to approve anything that moves funds.
+func botChat(ctx context.Context, b *tele.Bot, chat *tele.Chat, pa *agent.PrismAgent, chats *chatSessions, text string, tools []agent.Tool, op agent.Operator) error {
+	conv, err := chats.get(chat.ID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to load conversation for chat %d: %v\n", chat.ID, err)
+		_, err := b.Send(chat, "🤖 _Agent is thinking..._ (Memory unavailable)")
+		return err
+	}
 
-					resp, _ := pa.Talk(ctx, strings.Join(input, " "))
-					return c.Send("🤖 " + resp)
-				})
+	msg, err := b.Send(chat, "🤖 …")
+	if err != nil {
+		return err
+	}
+	var stream *agent.Stream
+	if tools == nil {
+		stream = pa.ChatStream(ctx, conv, text)
+	} else {
+		stream = pa.ActStream(ctx, conv, text, tools, op)
+	}
 
-				b.Handle("/agent", func(c tele.Context) error {
-					args := c.Args()
-					if len(args) > 1 && args[0] == "settle" {
-						amountSOL, _ := strconv.ParseFloat(args[1], 64)
-						lamports := uint64(amountSOL * 1e9)
-						
-						c.Send("🛰️ *Autonomous Settlement Triggered...*\nAgent-to-Agent Handshake in progress.")
-						
-						res, err := client.Shield(lamports, "PNPVau1t11111111111111111111111111111111111", "privacy_cash", false)
-						if err != nil {
-							return c.Send("❌ Agent Settlement failed: " + err.Error())
-						}
+	shown := "🤖 …"
+	edit := func(text string) {
+		if text == shown {
+			return
+		}
+		if _, err := b.Edit(msg, text); err == nil {
+			shown = text
+		}
+	}
+	var partial strings.Builder
+	last := time.Now()
+	for delta := range stream.Deltas {
+		partial.WriteString(delta)
+		if time.Since(last) >= botEditInterval {
+			edit("🤖 " + partial.String() + " …")
+			last = time.Now()
+		}
+	}
 
-						ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-						defer cancel()
-						resp, _ := pa.Talk(ctx, fmt.Sprintf("An agent just autonomously settled %f SOL. Give a technical report log summary.", amountSOL))
+	resp, err := stream.Wait()
+	switch {
+	case errors.Is(err, context.Canceled):
+		edit("🤖 " + partial.String() + " ⏹️")
+	case err != nil:
+		edit("🤖 _Agent is thinking..._ (Connection error)")
+	default:
+		if err := chats.save(chat.ID, conv); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Failed to save conversation for chat %d: %v\n", chat.ID, err)
+		}
+		edit("🤖 " + resp)
+	}
+	return nil
+}
 
-						return c.Send(fmt.Sprintf("✅ *Settlement Successful*\n\nHash: `%s`\n\n🤖 *Agent Report:* %s", res["tx_hash"], resp), tele.ModeMarkdown)
-					}
+// friendlyError turns a core failure into a chat-friendly message without
+// leaking raw HTTP details to the user.
+func friendlyError(action string, err error) string {
+	var ce *sidecar.CoreError
+	if !errors.As(err, &ce) {
+		return fmt.Sprintf("❌ %s: %v", action, err)
+	}
 
-					return c.Send("🛰️ *PNP Agent-to-Agent Portal*\n\n" +
-						"Status: `Listening`\n" +
-						"Last Ping: `Agent-772 (Discovery)`\n\n" +
-						"To trigger an autonomous agent settlement, use: `/agent settle [amount]`", tele.ModeMarkdown)
-				})
-
-				b.Handle(tele.OnText, func(c tele.Context) error {
-					ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-					defer cancel()
-
-					resp, err := pa.Talk(ctx, c.Text())
-					if err != nil {
-						return c.Send("🤖 _Agent is thinking..._ (Connection error)")
-					}
-					return c.Send("🤖 " + resp)
-				})
-
-		
-
-		
-
-			
+	switch ce.Kind {
+	case sidecar.KindTransport:
+		return "❌ " + action + ": Core Engine is unreachable."
+	case sidecar.KindUnauthorized:
+		return "🔒 " + action + ": the bot is not authorized to talk to the Core Engine."
+	case sidecar.KindComplianceBlocked:
+		return "🚨 " + action + ": Range Protocol flagged the destination as high risk. The transaction was blocked."
+	case sidecar.KindNotFound:
+		return "🔍 " + action + ": not found."
+	case sidecar.KindInvalidRequest:
+		return "💡 " + action + ": the request was rejected. Check the amount and address."
+	default:
+		return "⚠️ " + action + ": the privacy provider is having trouble. Please try again shortly."
+	}
+}
 
-		fmt.Println("🤖 Telegram Bot is now online!")
-		b.Start()
-	},
+// truncate shortens s to at most n bytes for display.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
 }
 
 func getRating(score int) string {
@@ -715,5 +608,7 @@ func getRating(score int) string {
 }
 
 func init() {
+	botCmd.Flags().BoolVar(&botPersistChats, "persist-chats", false, "Save each chat's AI conversation, encrypted, so it survives restarts")
+	botCmd.Flags().BoolVar(&botNoTools, "no-tools", false, "Only talk; don't let the assistant use ShadowPrism from chat")
 	rootCmd.AddCommand(botCmd)
 }
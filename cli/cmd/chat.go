@@ -3,51 +3,223 @@ package cmd
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/nathfavour/shadowprism/cli/internal/agent"
+	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
 	"github.com/spf13/cobra"
 )
 
+var (
+	chatSession string
+	chatDryRun  bool
+	chatNoTools bool
+)
+
 var chatCmd = &cobra.Command{
 	Use:   "chat",
 	Short: "Talk to ShadowPrism AI for privacy advice and system help",
-	Run: func(cmd *cobra.Command, args []string) {
-		pa := agent.NewPrismAgent()
-		scanner := bufio.NewScanner(os.Stdin)
+	Long: `Talk to ShadowPrism AI for privacy advice and system help.
+
+The assistant remembers earlier messages up to agent.history_tokens. With
+--session the conversation is saved, encrypted like your secrets, and
+resumed the next time the same session is opened.
+
+The assistant can check status, balance, prices, history and tasks for you,
+and propose shield, swap and pay actions. Nothing that moves funds is sent
+until you have seen the exact request and approved it; with --dry-run every
+proposal is shown and declined.
+
+Replies appear as they are written. Ctrl-C stops the reply in progress;
+at the prompt it leaves the chat.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if chatSession != "" {
+			if err := sidecar.ValidateSession(chatSession); err != nil {
+				return usageError(err)
+			}
+		}
+		pa, err := newAgent()
+		if err != nil {
+			return err
+		}
+		conv, err := openConversation(chatSession)
+		if err != nil {
+			return err
+		}
+		input := newChatInput(os.Stdin)
+
+		// Ctrl-C interrupts the reply in progress rather than the whole
+		// chat, so the chat runs outside the command context and handles
+		// signals itself.
+		session, stopSession := signal.NotifyContext(context.WithoutCancel(cmd.Context()), syscall.SIGTERM)
+		defer stopSession()
+
+		core := &lazyCore{}
+		defer core.Close()
+		var tools []agent.Tool
+		if !chatNoTools {
+			if tools, err = agentTools(core.client); err != nil {
+				return err
+			}
+		}
+		op := &terminalOperator{in: input, dryRun: chatDryRun}
 
 		fmt.Println("🌐 ShadowPrism Conversational AI Online.")
-		fmt.Println("Type 'exit' or 'quit' to leave the chat.")
+		if n := conv.Len(); n > 0 {
+			fmt.Printf("📜 Resumed session %q (%d messages).\n", chatSession, n)
+		}
+		fmt.Println("Type '/reset' to start over, 'exit' or 'quit' to leave the chat.")
 		fmt.Println("")
 
 		for {
 			fmt.Print("👤 You: ")
-			if !scanner.Scan() {
+			prompt, stop := signal.NotifyContext(session, os.Interrupt)
+			line, ok := input.read(prompt)
+			stop()
+			if !ok {
+				fmt.Println("")
 				break
 			}
-			input := scanner.Text()
-			if strings.ToLower(input) == "exit" || strings.ToLower(input) == "quit" {
+			if strings.ToLower(line) == "exit" || strings.ToLower(line) == "quit" {
 				break
 			}
-
-			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-			resp, err := pa.Talk(ctx, input)
-			cancel()
-
-			if err != nil {
-				fmt.Printf("❌ Error: %v\n", err)
+			if strings.TrimSpace(line) == "/reset" {
+				if err := resetConversation(chatSession, conv); err != nil {
+					fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+				} else {
+					fmt.Println("🧹 Conversation cleared.")
+				}
+				fmt.Println("")
 				continue
 			}
 
-			pa.DisplayResponse(resp)
+			err := chatTurn(session, pa, conv, line, tools, op)
+			switch {
+			case errors.Is(err, context.Canceled):
+				fmt.Fprintln(os.Stderr, "⏹️  Interrupted.")
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
+			case chatSession != "":
+				if err := saveConversation(chatSession, conv); err != nil {
+					fmt.Fprintf(os.Stderr, "⚠️  Failed to save session: %v\n", err)
+				}
+			}
 			fmt.Println("")
 		}
+		return input.Err()
 	},
 }
 
+// chatTurn streams the answer to line until it is complete or the user
+// presses Ctrl-C.
+func chatTurn(session context.Context, pa *agent.PrismAgent, conv *agent.Conversation, line string, tools []agent.Tool, op agent.Operator) error {
+	ctx, stop := signal.NotifyContext(session, os.Interrupt)
+	defer stop()
+
+	timeout := 15 * time.Second
+	if tools != nil {
+		timeout = toolTurnTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stream *agent.Stream
+	if tools == nil {
+		stream = pa.ChatStream(ctx, conv, line)
+	} else {
+		stream = pa.ActStream(ctx, conv, line, tools, op)
+	}
+	_, err := pa.DisplayStream(stream)
+	return err
+}
+
+// chatInput reads stdin in the background, so waiting for a line can be
+// abandoned when the user presses Ctrl-C.
+type chatInput struct {
+	lines  chan string
+	err    error
+	closed bool
+}
+
+func newChatInput(r io.Reader) *chatInput {
+	in := &chatInput{lines: make(chan string)}
+	go func() {
+		defer close(in.lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			in.lines <- scanner.Text()
+		}
+		in.err = scanner.Err()
+	}()
+	return in
+}
+
+// read returns the next line, or false at the end of input or when ctx is
+// done.
+func (in *chatInput) read(ctx context.Context) (string, bool) {
+	select {
+	case line, ok := <-in.lines:
+		in.closed = !ok
+		return line, ok
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+// Err is the error that ended the input, if it has ended.
+func (in *chatInput) Err() error {
+	if !in.closed {
+		return nil
+	}
+	return in.err
+}
+
+// terminalOperator asks for approval on the chat's own input, so answers
+// never get mixed up with the next message.
+type terminalOperator struct {
+	in     *chatInput
+	dryRun bool
+}
+
+func (o *terminalOperator) Confirm(ctx context.Context, action *agent.Action) bool {
+	fmt.Fprintf(os.Stderr, "\n📝 Proposed: %s\n%s\n", action.Summary, preview(action))
+	if o.dryRun {
+		fmt.Fprintln(os.Stderr, "🧪 Dry run: not sent.")
+		return false
+	}
+	fmt.Fprint(os.Stderr, "Send this request? [y/N]: ")
+	line, ok := o.in.read(ctx)
+	if !ok {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+func (o *terminalOperator) Done(action *agent.Action, result any, err error) {
+	if !action.MovesValue {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %s failed: %v\n", action.Tool, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "✅ %s sent.\n", action.Tool)
+	if action.Private != "" {
+		fmt.Fprintf(os.Stderr, "🧾 %s\n", action.Private)
+	}
+}
+
 func init() {
+	chatCmd.Flags().StringVar(&chatSession, "session", "", "Save the conversation under this name and resume it later")
+	chatCmd.Flags().BoolVar(&chatDryRun, "dry-run", false, "Show what the assistant proposes to send, but never send it")
+	chatCmd.Flags().BoolVar(&chatNoTools, "no-tools", false, "Only talk; don't let the assistant use ShadowPrism")
 	rootCmd.AddCommand(chatCmd)
 }
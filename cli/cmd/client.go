@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
+)
+
+// keepCore leaves a core spawned by this invocation running in the
+// background so later commands can attach to it.
+var keepCore bool
+
+// connectCore is the single way commands reach the core: it attaches to a
+// healthy engine on the socket or spawns one. Callers must Close the session.
+func connectCore(ctx context.Context) (*sidecar.Session, error) {
+	cm, err := configManager()
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := sidecar.EnsureCore(ctx, cm, sidecar.EnsureOptions{
+		Port:         settings.Int("core.port"),
+		StartTimeout: settings.Duration("core.start_timeout"),
+		Detach:       keepCore,
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, withExitCode(exitUnreachable, err)
+	}
+
+	if sess.Spawned {
+		if keepCore {
+			fmt.Fprintln(os.Stderr, "🚀 Started ShadowPrism Core in the background.")
+		} else {
+			fmt.Fprintln(os.Stderr, "🚀 Started ShadowPrism Core for this session.")
+		}
+	}
+	return sess, nil
+}
+
+// profileConfig is the active profile, opened once so a passphrase-protected
+// key is only asked for once per command.
+var profileConfig *sidecar.ConfigManager
+
+func configManager() (*sidecar.ConfigManager, error) {
+	if profileConfig != nil {
+		return profileConfig, nil
+	}
+	cm, err := sidecar.NewConfigManager()
+	if err != nil {
+		return nil, err
+	}
+	profileConfig = cm
+	return cm, nil
+}
+
+// resolveAddress expands an @alias from the address book and validates the
+// resulting address.
+func resolveAddress(input string) (string, error) {
+	cm, err := configManager()
+	if err != nil {
+		return "", err
+	}
+	return cm.ResolveAddress(input)
+}
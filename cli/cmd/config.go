@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
 	"github.com/spf13/cobra"
@@ -11,29 +16,277 @@ import (
 var configCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Configure ShadowPrism settings",
+	Long: `Configure ShadowPrism settings.
+
+Settings live in ~/.shadowprism/config.yaml, or the file given with --config
+or $SHADOWPRISM_CONFIG, so a team can commit one per network. Flags win over
+environment variables, which win over the file, which wins over defaults.`,
+	// Skip loading settings up front so a broken file can still be fixed.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		sidecar.SettingsFile = configPath
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, ok := sidecar.LookupSetting(args[0]); !ok {
+			return usageError(fmt.Errorf("unknown setting %q (see `shadowprism config list`)", args[0]))
+		}
+		s, err := loadConfigSettings()
+		if err != nil {
+			return err
+		}
+		fmt.Println(s.Get(args[0]))
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set [key] [value]",
+	Short: "Save a setting to the settings file",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := loadConfigSettings()
+		if err != nil {
+			return err
+		}
+		if err := s.Set(args[0], args[1]); err != nil {
+			return usageError(err)
+		}
+		if err := s.Save(); err != nil {
+			return fmt.Errorf("failed to save settings: %w", err)
+		}
+		fmt.Printf("✅ %s = %s\n", args[0], args[1])
+
+		if setting, _ := sidecar.LookupSetting(args[0]); os.Getenv(setting.EnvVar()) != "" {
+			fmt.Printf("⚠️  %s is set in the environment and takes precedence.\n", setting.EnvVar())
+		}
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset [key]",
+	Short: "Remove a setting from the settings file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := loadConfigSettings()
+		if err != nil {
+			return err
+		}
+		if err := s.Unset(args[0]); err != nil {
+			return usageError(err)
+		}
+		if err := s.Save(); err != nil {
+			return fmt.Errorf("failed to save settings: %w", err)
+		}
+		fmt.Printf("✅ %s reset to %q\n", args[0], s.Get(args[0]))
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every setting with its value and source",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := loadConfigSettings()
+		if err != nil {
+			return err
+		}
+
+		rows := make([]settingRow, len(sidecar.Schema))
+		for i, setting := range sidecar.Schema {
+			value, source := s.Lookup(setting.Key)
+			if source == sidecar.SourceEnv {
+				source = sidecar.SettingSource("env " + setting.EnvVar())
+			}
+			rows[i] = settingRow{Key: setting.Key, Value: value, Source: string(source), Description: setting.Help}
+		}
+
+		return render(rows, func() {
+			fmt.Printf("📄 %s\n\n", s.Path())
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tVALUE\tSOURCE\tDESCRIPTION")
+			for _, row := range rows {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row.Key, row.Value, row.Source, row.Description)
+			}
+			w.Flush()
+		})
+	},
+}
+
+// settingRow is one setting in the machine-readable output of config list.
+type settingRow struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Source      string `json:"source"`
+	Description string `json:"description"`
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the settings file in $EDITOR and validate it",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
+		if err != nil {
+			return err
+		}
+		path := cm.SettingsPath()
+
+		original, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if len(original) == 0 {
+			original = []byte(settingsTemplate())
+		}
+
+		// Edit a copy so an invalid file never replaces a working one.
+		tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.yaml")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		tmp.Write(original)
+		tmp.Close()
+
+		for {
+			if err := runEditor(tmp.Name()); err != nil {
+				return fmt.Errorf("editor failed: %w", err)
+			}
+			edited, err := os.ReadFile(tmp.Name())
+			if err != nil {
+				return err
+			}
+
+			if _, err := sidecar.ParseSettings(edited); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Invalid settings:\n%v\n", err)
+				if confirm("Edit again?") {
+					continue
+				}
+				return withExitCode(exitUsage, errors.New("changes discarded"))
+			}
+
+			if err := os.WriteFile(path, edited, 0600); err != nil {
+				return fmt.Errorf("failed to save settings: %w", err)
+			}
+			fmt.Printf("✅ Saved %s\n", path)
+			return nil
+		}
+	},
+}
+
+// settingsTemplate seeds a new settings file with every key commented out.
+func settingsTemplate() string {
+	var b strings.Builder
+	b.WriteString("# ShadowPrism settings. Uncomment a line to override its default.\n")
+	for _, s := range sidecar.Schema {
+		fmt.Fprintf(&b, "\n# %s\n# %s: %q\n", s.Help, s.Key, s.Default)
+	}
+	return b.String()
+}
+
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// $EDITOR may carry arguments, e.g. "code --wait".
+	parts := strings.Fields(editor)
+	c := exec.Command(parts[0], append(parts[1:], path)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return c.Run()
+}
+
+// loadConfigSettings loads settings for config commands, which skip the
+// root's loadSettings so a broken file can still be repaired.
+func loadConfigSettings() (*sidecar.Settings, error) {
+	cm, err := configManager()
+	if err != nil {
+		return nil, err
+	}
+	s, err := cm.LoadSettings()
+	if err != nil {
+		return nil, fmt.Errorf("%w\nFix it with: shadowprism config edit", err)
+	}
+	return s, nil
 }
 
 var setBotTokenCmd = &cobra.Command{
 	Use:   "set-bot-token [token]",
 	Short: "Securely save the Telegram Bot token",
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		cm, err := sidecar.NewConfigManager()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
 		if err != nil {
-			fmt.Printf("❌ Error: %v\n", err)
-			os.Exit(1)
+			return err
 		}
 
 		if err := cm.SaveSecret("tg_bot_token", args[0]); err != nil {
-			fmt.Printf("❌ Failed to save token: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to save token: %w", err)
 		}
 
 		fmt.Println("✅ Telegram Bot token saved and encrypted in ~/.shadowprism")
+		return nil
+	},
+}
+
+var keySourceCmd = &cobra.Command{
+	Use:   "key-source [machine|passphrase|env|keyring]",
+	Short: "Show or change how stored secrets are encrypted",
+	Long: `Show or change the key that encrypts secrets in ~/.shadowprism.
+
+  machine     derived from the hostname (legacy default, guessable)
+  passphrase  Argon2id-stretched passphrase, read from a prompt or SHADOWPRISM_PASSPHRASE
+  env         32-byte key from SHADOWPRISM_MASTER_KEY, hex or base64 (containers)
+  keyring     random key kept in the Secret Service keyring via secret-tool
+
+Changing the source re-encrypts every stored secret. Choosing passphrase
+again sets a new passphrase.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: sidecar.KeySources,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
+		if err != nil {
+			return err
+		}
+
+		current, err := cm.KeySourceName()
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			fmt.Printf("🔐 Secrets are encrypted with the %s key source.\n", current)
+			return nil
+		}
+
+		src, err := cm.NewKeySource(args[0])
+		if err != nil {
+			return usageError(err)
+		}
+		if src.Name() == current && current != sidecar.KeySourcePassphrase {
+			fmt.Printf("🔐 Secrets are already encrypted with the %s key source.\n", current)
+			return nil
+		}
+
+		n, err := cm.SetKeySource(src)
+		if err != nil {
+			return fmt.Errorf("failed to change key source: %w", err)
+		}
+		fmt.Printf("✅ Re-encrypted %d secret(s) with the %s key source.\n", n, src.Name())
+		return nil
 	},
 }
 
 func init() {
-	configCmd.AddCommand(setBotTokenCmd)
+	configCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd, configListCmd, configEditCmd)
+	configCmd.AddCommand(setBotTokenCmd, keySourceCmd)
 	rootCmd.AddCommand(configCmd)
 }
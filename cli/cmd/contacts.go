@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	contactNote    string
+	contactReplace bool
+)
+
+var contactsCmd = &cobra.Command{
+	Use:   "contacts",
+	Short: "Manage the encrypted address book",
+	Long: `Manage the encrypted address book.
+
+Aliases stand in for addresses wherever a destination or merchant is
+expected, e.g. ` + "`shadowprism shield 1sol @treasury`" + `. The book is encrypted
+with the same key as your secrets.`,
+}
+
+var contactsAddCmd = &cobra.Command{
+	Use:   "add [alias] [address]",
+	Short: "Save an address under an alias",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
+		if err != nil {
+			return err
+		}
+		c, err := cm.AddContact(args[0], args[1], contactNote, contactReplace)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Saved @%s → %s\n", c.Alias, c.Address)
+		return nil
+	},
+}
+
+var contactsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved contacts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
+		if err != nil {
+			return err
+		}
+		book, err := cm.LoadAddressBook()
+		if err != nil {
+			return err
+		}
+		contacts := book.Contacts()
+		rows := make([]contactRow, len(contacts))
+		for i, c := range contacts {
+			rows[i] = contactRow{Alias: c.Alias, Address: c.Address, Note: c.Note, AddedAt: c.AddedAt}
+		}
+
+		return render(rows, func() {
+			if len(rows) == 0 {
+				fmt.Println("⚪ No contacts saved.")
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ALIAS\tADDRESS\tNOTE")
+			for _, c := range rows {
+				fmt.Fprintf(w, "@%s\t%s\t%s\n", c.Alias, c.Address, c.Note)
+			}
+			w.Flush()
+		})
+	},
+}
+
+// contactRow is one entry in the machine-readable output of contacts list.
+type contactRow struct {
+	Alias   string    `json:"alias"`
+	Address string    `json:"address"`
+	Note    string    `json:"note"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+var contactsRmCmd = &cobra.Command{
+	Use:     "rm [alias]",
+	Aliases: []string{"remove"},
+	Short:   "Remove a contact",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
+		if err != nil {
+			return err
+		}
+		if err := cm.RemoveContact(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("🗑️  Removed %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	contactsAddCmd.Flags().StringVar(&contactNote, "note", "", "Free-form note stored with the contact")
+	contactsAddCmd.Flags().BoolVarP(&contactReplace, "force", "f", false, "Replace an existing contact with the same alias")
+	contactsCmd.AddCommand(contactsAddCmd, contactsListCmd, contactsRmCmd)
+	rootCmd.AddCommand(contactsCmd)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/internal/agent"
+)
+
+// chatTranscript is a conversation as sealed in the secret store.
+type chatTranscript struct {
+	Messages  []agent.Message `json:"messages"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// openConversation starts a conversation bounded by agent.history_tokens,
+// resuming the transcript of session when one is saved. An empty session is
+// kept in memory only.
+func openConversation(session string) (*agent.Conversation, error) {
+	budget := settings.Int("agent.history_tokens")
+	if session == "" {
+		return agent.NewConversation(budget, nil), nil
+	}
+
+	cm, err := configManager()
+	if err != nil {
+		return nil, err
+	}
+	var t chatTranscript
+	if _, err := cm.LoadTranscript(session, &t); err != nil {
+		return nil, err
+	}
+	return agent.NewConversation(budget, t.Messages), nil
+}
+
+// saveConversation seals conv as the transcript of session.
+func saveConversation(session string, conv *agent.Conversation) error {
+	cm, err := configManager()
+	if err != nil {
+		return err
+	}
+	return cm.SaveTranscript(session, chatTranscript{Messages: conv.History(), UpdatedAt: time.Now().UTC()})
+}
+
+// resetConversation forgets conv and deletes the transcript of session.
+func resetConversation(session string, conv *agent.Conversation) error {
+	conv.Reset()
+	if session == "" {
+		return nil
+	}
+	cm, err := configManager()
+	if err != nil {
+		return err
+	}
+	return cm.DeleteTranscript(session)
+}
+
+// chatSessions keeps one conversation per Telegram chat, saving transcripts
+// when persist is set.
+type chatSessions struct {
+	persist bool
+
+	mu      sync.Mutex
+	convs   map[int64]*agent.Conversation
+	replies map[int64]map[int]context.CancelFunc
+	nextID  int
+}
+
+func newChatSessions(persist bool) *chatSessions {
+	return &chatSessions{
+		persist: persist,
+		convs:   map[int64]*agent.Conversation{},
+		replies: map[int64]map[int]context.CancelFunc{},
+	}
+}
+
+// begin records a reply in progress in chatID so stop can cancel it. Call
+// the returned func when the reply is over.
+func (s *chatSessions) begin(chatID int64, cancel context.CancelFunc) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := s.nextID
+	if s.replies[chatID] == nil {
+		s.replies[chatID] = map[int]context.CancelFunc{}
+	}
+	s.replies[chatID][id] = cancel
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.replies[chatID], id)
+		if len(s.replies[chatID]) == 0 {
+			delete(s.replies, chatID)
+		}
+	}
+}
+
+// stop cancels every reply in progress in chatID and reports whether there
+// were any.
+func (s *chatSessions) stop(chatID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cancel := range s.replies[chatID] {
+		cancel()
+	}
+	return len(s.replies[chatID]) > 0
+}
+
+// sessionName is the transcript name for a Telegram chat. Group chat IDs
+// are negative.
+func (s *chatSessions) sessionName(chatID int64) string {
+	if !s.persist {
+		return ""
+	}
+	if chatID < 0 {
+		return fmt.Sprintf("telegram-g%d", -chatID)
+	}
+	return fmt.Sprintf("telegram-%d", chatID)
+}
+
+// get returns the conversation for chatID, resuming its transcript the first
+// time the chat is seen.
+func (s *chatSessions) get(chatID int64) (*agent.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if conv, ok := s.convs[chatID]; ok {
+		return conv, nil
+	}
+	conv, err := openConversation(s.sessionName(chatID))
+	if err != nil {
+		return nil, err
+	}
+	s.convs[chatID] = conv
+	return conv, nil
+}
+
+// save persists the conversation of chatID, if transcripts are kept.
+func (s *chatSessions) save(chatID int64, conv *agent.Conversation) error {
+	if !s.persist {
+		return nil
+	}
+	return saveConversation(s.sessionName(chatID), conv)
+}
+
+// reset forgets the conversation of chatID.
+func (s *chatSessions) reset(chatID int64) error {
+	conv, err := s.get(chatID)
+	if err != nil {
+		return err
+	}
+	return resetConversation(s.sessionName(chatID), conv)
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nathfavour/shadowprism/cli/internal/address"
+	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes. Scripts can rely on these; add new ones rather than
+// renumbering.
+const (
+	exitOK          = 0
+	exitError       = 1 // anything not listed below
+	exitUsage       = 2 // bad arguments, flags, amounts or addresses
+	exitUnreachable = 3 // no healthy core; also `core status` when stopped
+	exitCompliance  = 4 // Range Protocol blocked the destination
+	exitProvider    = 5 // a privacy provider or the chain failed the request
+	exitTimeout     = 6 // a deadline passed, e.g. task --wait --timeout
+	exitInterrupted = 130
+)
+
+// codedError carries a specific exit code for err. A nil err exits
+// silently, for commands that already reported the outcome.
+type codedError struct {
+	code int
+	err  error
+	// showUsage prints the command's usage after the error.
+	showUsage bool
+}
+
+func (e *codedError) Error() string {
+	if e.err == nil {
+		return ""
+	}
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode makes err exit the process with code.
+func withExitCode(code int, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// usageError marks err as a mistake in how the command was invoked.
+func usageError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return withExitCode(exitUsage, err)
+}
+
+// exitCode maps err onto the exit-code table.
+func exitCode(err error) int {
+	var coded *codedError
+	var ce *sidecar.CoreError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	case errors.Is(err, address.ErrInvalid), errors.Is(err, address.ErrOffCurve),
+		errors.Is(err, sidecar.ErrInvalidAlias), errors.Is(err, sidecar.ErrContactNotFound),
+		errors.Is(err, sidecar.ErrContactExists):
+		return exitUsage
+	case errors.As(err, &ce):
+		switch ce.Kind {
+		case sidecar.KindTransport, sidecar.KindUnauthorized:
+			return exitUnreachable
+		case sidecar.KindComplianceBlocked:
+			return exitCompliance
+		case sidecar.KindInvalidRequest:
+			return exitUsage
+		case sidecar.KindUpstreamFailure:
+			return exitProvider
+		}
+	}
+	return exitError
+}
+
+// invocationError is a usage error from cobra's own argument or flag
+// checks, which is worth showing the usage for.
+func invocationError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: exitUsage, err: err, showUsage: true}
+}
+
+// markUsageErrors makes argument validation failures of cmd and its
+// subcommands exit with exitUsage. Flag errors are handled on the root.
+func markUsageErrors(cmd *cobra.Command) {
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(cmd *cobra.Command, a []string) error {
+			return invocationError(args(cmd, a))
+		}
+	}
+	for _, c := range cmd.Commands() {
+		markUsageErrors(c)
+	}
+}
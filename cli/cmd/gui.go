@@ -1,13 +1,9 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"os"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
 	"github.com/nathfavour/shadowprism/cli/internal/ui"
 	"github.com/spf13/cobra"
 )
@@ -15,32 +11,34 @@ import (
 var guiCmd = &cobra.Command{
 	Use:   "gui",
 	Short: "Launch the ShadowPrism TUI",
-	        Run: func(cmd *cobra.Command, args []string) {
-	                authToken := "dev-token-123"
-	
-	                manager := sidecar.NewManager(42069, authToken)
-			cm, err := sidecar.NewConfigManager()
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
 		if err != nil {
-			fmt.Printf("❌ Error: %v\n", err)
-			os.Exit(1)
+			return err
+		}
+		// Unlock the address book before the TUI takes over the terminal.
+		book, err := cm.LoadAddressBook()
+		if err != nil {
+			return err
 		}
 
-		fmt.Println("🚀 Starting ShadowPrism Core...")
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+		pa, err := newAgent()
+		if err != nil {
+			return err
+		}
 
-		if err := manager.Start(ctx); err != nil {
-			fmt.Printf("❌ Failed to start core engine: %v\n", err)
-			os.Exit(1)
+		sess, err := connectCore(cmd.Context())
+		if err != nil {
+			return err
 		}
-		defer manager.Stop()
+		defer sess.Close()
+		client := sess.Client
 
-		client := sidecar.NewCoreClient(cm.GetSocketPath(), authToken)
-		p := tea.NewProgram(ui.InitialModel(client), tea.WithAltScreen())
+		p := tea.NewProgram(ui.InitialModel(cmd.Context(), client, pa, sess.Events(), sess.Logs(), book), tea.WithAltScreen())
 		if _, err := p.Run(); err != nil {
-			fmt.Printf("Alas, there's been an error: %v", err)
-			os.Exit(1)
+			return fmt.Errorf("alas, there's been an error: %w", err)
 		}
+		return nil
 	},
 }
 
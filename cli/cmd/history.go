@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/api"
+	"github.com/nathfavour/shadowprism/cli/internal/amount"
+	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyStatus      string
+	historyProvider    string
+	historyDestination string
+	historySince       string
+	historyUntil       string
+	historyLimit       int
+	historyOffset      int
+
+	exportFormat       string
+	exportFile         string
+	exportIncludeNotes bool
+)
+
+// exportPageSize is how many rows history export asks the core for at once.
+const exportPageSize = 500
+
+var historyStatuses = []api.TaskStatus{api.StatusPending, api.StatusBroadcast, api.StatusConfirmed, api.StatusFailed}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List past transactions, newest first",
+	Long: `List past transactions, newest first.
+
+--since and --until take a date (2026-01-31), a timestamp
+(2026-01-31T09:00:00Z) or an age like 24h or 7d. --until is exclusive.
+The destination is a Solana address or an @alias from the address book.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		q, err := historyQuery()
+		if err != nil {
+			return err
+		}
+
+		sess, err := connectCore(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer sess.Close()
+
+		records, err := sess.Client.GetHistory(cmd.Context(), q)
+		if err != nil {
+			return err
+		}
+
+		return render(records, func() {
+			if len(records) == 0 {
+				fmt.Println("📜 No transactions match.")
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "\tCREATED\tSTATUS\tAMOUNT\tPROVIDER\tDESTINATION\tTX")
+			for _, rec := range records {
+				tx := api.Deref(rec.TxHash)
+				if tx == "" {
+					tx = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					statusEmoji(rec.Status),
+					rec.CreatedAt.Local().Format("2006-01-02 15:04"),
+					rec.Status,
+					amount.SOL(rec.AmountLamports),
+					rec.Provider,
+					rec.Destination,
+					tx)
+			}
+			w.Flush()
+			if len(records) == q.Limit {
+				fmt.Printf("\nMore may follow: --offset %d\n", q.Offset+len(records))
+			}
+		})
+	},
+}
+
+var historyExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write matching transactions as CSV or JSON for accounting",
+	Long: `Write every matching transaction as CSV or JSON, newest first, to
+stdout or --file. Takes the same filters as history; without --limit
+all matching rows are exported.
+
+Privacy notes are left out unless --include-notes is given: anyone holding
+a note can withdraw the shielded funds.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportFormat != "csv" && exportFormat != "json" {
+			return usageError(fmt.Errorf("invalid --format %q (want csv or json)", exportFormat))
+		}
+		q, err := historyQuery()
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("limit") {
+			q.Limit = 0
+		}
+
+		sess, err := connectCore(cmd.Context())
+		if err != nil {
+			return err
+		}
+		records, err := fetchAllHistory(cmd, sess.Client, q)
+		sess.Close()
+		if err != nil {
+			return err
+		}
+		if !exportIncludeNotes {
+			for i := range records {
+				records[i].Note = nil
+			}
+		}
+
+		out := io.Writer(os.Stdout)
+		if exportFile != "" {
+			f, err := os.OpenFile(exportFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+
+		if exportFormat == "json" {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(records)
+		} else {
+			err = writeHistoryCSV(out, records)
+		}
+		if err != nil {
+			return err
+		}
+		if exportFile != "" {
+			fmt.Fprintf(os.Stderr, "✅ Exported %d transaction(s) to %s\n", len(records), exportFile)
+		}
+		return nil
+	},
+}
+
+// fetchAllHistory pages through the core until q.Limit rows, or every row
+// when q.Limit is zero, have been read.
+func fetchAllHistory(cmd *cobra.Command, client *sidecar.CoreClient, q api.HistoryQuery) ([]api.TransactionRecord, error) {
+	want := q.Limit
+	records := []api.TransactionRecord{}
+	for {
+		q.Limit = exportPageSize
+		if want > 0 && want-len(records) < q.Limit {
+			q.Limit = want - len(records)
+		}
+		page, err := client.GetHistory(cmd.Context(), q)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, page...)
+		if len(page) < q.Limit || (want > 0 && len(records) >= want) {
+			return records, nil
+		}
+		q.Offset += len(page)
+	}
+}
+
+func writeHistoryCSV(w io.Writer, records []api.TransactionRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "created_at", "status", "provider", "amount_lamports", "amount_sol", "destination", "tx_hash", "note"})
+	for _, rec := range records {
+		cw.Write([]string{
+			rec.ID,
+			rec.CreatedAt.UTC().Format(time.RFC3339),
+			string(rec.Status),
+			rec.Provider,
+			strconv.FormatUint(rec.AmountLamports, 10),
+			amount.FormatNumber(rec.AmountLamports, 9),
+			rec.Destination,
+			api.Deref(rec.TxHash),
+			api.Deref(rec.Note),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// historyQuery builds the core query from the filter flags.
+func historyQuery() (api.HistoryQuery, error) {
+	q := api.HistoryQuery{
+		Provider: historyProvider,
+		Limit:    historyLimit,
+		Offset:   historyOffset,
+	}
+	if q.Limit < 1 || q.Limit > api.MaxHistoryLimit {
+		return q, usageError(fmt.Errorf("--limit must be between 1 and %d", api.MaxHistoryLimit))
+	}
+	if q.Offset < 0 {
+		return q, usageError(errors.New("--offset can't be negative"))
+	}
+
+	if historyStatus != "" {
+		for _, s := range historyStatuses {
+			if strings.EqualFold(historyStatus, string(s)) {
+				q.Status = s
+			}
+		}
+		if q.Status == "" {
+			return q, usageError(fmt.Errorf("invalid --status %q (want one of %s)", historyStatus, joinStatuses()))
+		}
+	}
+
+	if historyDestination != "" {
+		dest, err := resolveAddress(historyDestination)
+		if err != nil {
+			return q, err
+		}
+		q.Destination = dest
+	}
+
+	var err error
+	if q.Since, err = parseWhen(historySince, time.Now()); err != nil {
+		return q, usageError(fmt.Errorf("invalid --since: %w", err))
+	}
+	if q.Until, err = parseWhen(historyUntil, time.Now()); err != nil {
+		return q, usageError(fmt.Errorf("invalid --until: %w", err))
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Since.Before(q.Until) {
+		return q, usageError(errors.New("--since must be before --until"))
+	}
+	return q, nil
+}
+
+// parseWhen reads a date, an RFC 3339 timestamp, or an age before now such
+// as 90m, 24h or 7d. Dates are midnight local time. "" is the zero time.
+func parseWhen(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date, timestamp or age like 7d", s)
+}
+
+func statusEmoji(s api.TaskStatus) string {
+	switch s {
+	case api.StatusConfirmed:
+		return "✅"
+	case api.StatusFailed:
+		return "❌"
+	}
+	return "⏳"
+}
+
+func joinStatuses() string {
+	names := make([]string, len(historyStatuses))
+	for i, s := range historyStatuses {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	flags := historyCmd.PersistentFlags()
+	flags.StringVar(&historyStatus, "status", "", "Only transactions with this status ("+joinStatuses()+")")
+	flags.StringVar(&historyProvider, "provider", "", "Only transactions routed through this provider")
+	flags.StringVar(&historyDestination, "destination", "", "Only transactions to this address or @alias")
+	flags.StringVar(&historySince, "since", "", "Only transactions at or after this time")
+	flags.StringVar(&historyUntil, "until", "", "Only transactions before this time")
+	flags.IntVar(&historyLimit, "limit", 20, "Maximum number of transactions")
+	flags.IntVar(&historyOffset, "offset", 0, "Skip this many of the newest matching transactions")
+
+	historyExportCmd.Flags().StringVar(&exportFormat, "format", "csv", "Export format: csv or json")
+	historyExportCmd.Flags().StringVar(&exportFile, "file", "", "Write to this file instead of stdout")
+	historyExportCmd.Flags().BoolVar(&exportIncludeNotes, "include-notes", false, "Include privacy notes, which can withdraw the funds")
+
+	historyCmd.AddCommand(historyExportCmd)
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats for --output. Text is the human output with emoji and agent
+// commentary; the others are rendered from a command's typed result.
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+	outputPlain = "plain"
+)
+
+var outputFormats = []string{outputText, outputJSON, outputYAML, outputTable, outputPlain}
+
+// outputFormat is the --output flag.
+var outputFormat = outputText
+
+func checkOutputFormat() error {
+	for _, f := range outputFormats {
+		if outputFormat == f {
+			return nil
+		}
+	}
+	return usageError(fmt.Errorf("invalid --output %q (want one of %s)", outputFormat, strings.Join(outputFormats, ", ")))
+}
+
+// textOutput reports whether output is for people. Progress lines and agent
+// commentary are only printed then, so scripts get nothing but the result.
+func textOutput() bool {
+	return outputFormat == outputText
+}
+
+// progress prints a status line in text mode.
+func progress(format string, args ...any) {
+	if textOutput() {
+		fmt.Printf(format, args...)
+	}
+}
+
+// render writes v in the --output format, or calls text in text mode. v is a
+// struct or a slice of structs; field names come from their json tags, so
+// every format uses the same stable names.
+func render(v any, text func()) error {
+	if err := checkOutputFormat(); err != nil {
+		return err
+	}
+	switch outputFormat {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputYAML:
+		return writeYAML(os.Stdout, v)
+	case outputTable:
+		return writeRows(os.Stdout, v, true)
+	case outputPlain:
+		return writeRows(os.Stdout, v, false)
+	}
+	text()
+	return nil
+}
+
+// writeYAML goes through JSON so YAML keys match the json tags and keep
+// their declaration order.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	clearStyle(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// clearStyle drops the flow and quoting styles YAML keeps from JSON input.
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
+
+// writeRows prints one row per element of v, or a single row for a struct.
+// Tables get a header and aligned columns; plain output is tab-separated
+// values only, for cut and awk.
+func writeRows(w io.Writer, v any, header bool) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	var rows []reflect.Value
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, reflect.Indirect(rv.Index(i)))
+		}
+	} else {
+		rows = []reflect.Value{rv}
+	}
+
+	elem := rv.Type()
+	if rv.Kind() == reflect.Slice {
+		elem = elem.Elem()
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("can't render %s as rows", elem)
+	}
+	fields, names := columns(elem)
+
+	out := w
+	var tw *tabwriter.Writer
+	if header {
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		out = tw
+		fmt.Fprintln(out, strings.ToUpper(strings.Join(names, "\t")))
+	}
+	for _, row := range rows {
+		cells := make([]string, len(fields))
+		for i, f := range fields {
+			cells[i] = cell(row.Field(f), header)
+		}
+		fmt.Fprintln(out, strings.Join(cells, "\t"))
+	}
+	if tw != nil {
+		return tw.Flush()
+	}
+	return nil
+}
+
+// columns lists the exported fields of t that have a json name.
+func columns(t reflect.Type) (fields []int, names []string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, i)
+		names = append(names, name)
+	}
+	return fields, names
+}
+
+func cell(v reflect.Value, table bool) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if table {
+				return "-"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case string:
+		if x == "" && table {
+			return "-"
+		}
+		return x
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format: "+strings.Join(outputFormats, ", "))
+}
@@ -1,54 +1,79 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"strconv"
-	"time"
 
-	"github.com/nathfavour/shadowprism/cli/internal/agent"
-	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
+	"github.com/nathfavour/shadowprism/cli/internal/amount"
 	"github.com/spf13/cobra"
 )
 
 var payCmd = &cobra.Command{
 	Use:   "pay [merchant_id] [amount]",
 	Short: "Private payment to a Starpay merchant",
-	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		merchant := args[0]
-		amount, err := strconv.ParseUint(args[1], 10, 64)
+	Long: `Pay a Starpay merchant privately.
+
+The amount takes a unit, like 0.5sol or "1.25 SOL", or is a share of
+your SOL balance, like 10%. A bare decimal like 0.2 is SOL; a bare whole
+number is in lamports.
+
+The merchant is a Solana address or an @alias from the address book.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		merchant, err := resolveAddress(args[0])
+		if err != nil {
+			return err
+		}
+		amt, err := amount.Parse(args[1], amount.Options{Token: "SOL"})
 		if err != nil {
-			fmt.Println("❌ Invalid amount.")
-			return
+			return usageError(err)
 		}
 
-		pa := agent.NewPrismAgent()
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+		sess, err := connectCore(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer sess.Close()
+		client := sess.Client
 
-		cm, _ := sidecar.NewConfigManager()
-		socketPath := cm.GetSocketPath()
-		client := sidecar.NewCoreClient(socketPath, "dev-token-123")
+		lamports, err := client.ResolveAmount(cmd.Context(), amt)
+		if err != nil {
+			return err
+		}
 
-		fmt.Printf("💳 Sending private payment of %d lamports to %s...\n", amount, merchant)
+		progress("💳 Sending private payment of %s to %s...\n", amount.SOL(lamports), merchant)
 
-		res, err := client.Pay(amount, merchant)
+		res, err := client.Pay(cmd.Context(), lamports, merchant)
 		if err != nil {
-			fmt.Printf("❌ Payment failed: %v\n", err)
-			return
+			return err
 		}
 
-		fmt.Printf("✅ Payment Successful!\n")
-		fmt.Printf("🔗 TX: %s\n", res["tx_hash"])
-		fmt.Printf("🧾 Receipt ID: %s\n", res["receipt_id"])
+		result := payResult{
+			Status:         res.Status,
+			TxHash:         res.TxHash,
+			ReceiptID:      res.ReceiptID,
+			Merchant:       merchant,
+			AmountLamports: lamports,
+		}
+		return render(result, func() {
+			fmt.Printf("✅ Payment Successful!\n")
+			fmt.Printf("🔗 TX: %s\n", res.TxHash)
+			fmt.Printf("🧾 Receipt ID: %s\n", res.ReceiptID)
 
-		// Agent feedback
-		resp, _ := pa.Talk(ctx, fmt.Sprintf("The user just paid %d lamports to merchant %s. Give a professional receipt confirmation.", amount, merchant))
-		pa.DisplayResponse(resp)
+			// Agent feedback
+			agentComment(cmd.Context(), fmt.Sprintf("The user just paid %s to merchant %s. Give a professional receipt confirmation.", amount.SOL(lamports), merchant))
+		})
 	},
 }
 
+// payResult is the machine-readable output of pay.
+type payResult struct {
+	Status         string `json:"status"`
+	TxHash         string `json:"tx_hash"`
+	ReceiptID      string `json:"receipt_id"`
+	Merchant       string `json:"merchant"`
+	AmountLamports uint64 `json:"amount_lamports"`
+}
+
 func init() {
 	rootCmd.AddCommand(payCmd)
 }
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileNetwork     string
+	profileDeleteForce bool
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage isolated ShadowPrism environments",
+	Long: `Manage isolated ShadowPrism environments.
+
+Each profile has its own core socket, secrets, settings, wallet and database,
+so a devnet and a mainnet core can run side by side. The default profile
+lives in ~/.shadowprism; others live in ~/.shadowprism/profiles/<name>.
+Pick one per command with --profile or $SHADOWPRISM_PROFILE.`,
+	// Profile commands must work even if the active profile is gone.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := sidecar.ListProfiles()
+		if err != nil {
+			return err
+		}
+		active, _ := sidecar.ActiveProfile()
+
+		rows := make([]profileRow, 0, len(names))
+		for _, name := range names {
+			row := profileRow{Name: name, Active: name == active, Network: "?", Core: "stopped"}
+			if cm, err := sidecar.OpenProfile(name); err == nil {
+				if s, err := cm.LoadSettings(); err == nil {
+					row.Network = s.Get("network")
+				}
+				if _, err := sidecar.Probe(cmd.Context(), cm); err == nil {
+					row.Core = "running"
+				}
+			}
+			rows = append(rows, row)
+		}
+
+		return render(rows, func() {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "\tPROFILE\tNETWORK\tCORE")
+			for _, row := range rows {
+				marker := ""
+				if row.Active {
+					marker = "*"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", marker, row.Name, row.Network, row.Core)
+			}
+			w.Flush()
+		})
+	},
+}
+
+// profileRow is one profile in the machine-readable output of profile list.
+type profileRow struct {
+	Name    string `json:"name"`
+	Active  bool   `json:"active"`
+	Network string `json:"network"`
+	Core    string `json:"core"`
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create an empty profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := sidecar.CreateProfile(args[0])
+		if err != nil {
+			return err
+		}
+
+		if profileNetwork != "" {
+			s, err := cm.LoadSettings()
+			if err == nil {
+				err = s.Set("network", profileNetwork)
+			}
+			if err == nil {
+				err = s.Save()
+			}
+			if err != nil {
+				fmt.Printf("⚠️  Profile created, but the network was not saved: %v\n", err)
+			}
+		}
+
+		fmt.Printf("✅ Created profile %q in %s\n", cm.Profile, cm.HomeDir)
+		fmt.Printf("Switch to it with: shadowprism profile use %s\n", cm.Profile)
+		return nil
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "Make a profile the default for later commands",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := sidecar.UseProfile(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Now using profile %q\n", args[0])
+		if env := os.Getenv("SHADOWPRISM_PROFILE"); env != "" && env != args[0] {
+			fmt.Printf("⚠️  SHADOWPRISM_PROFILE=%s is set and takes precedence.\n", env)
+		}
+		return nil
+	},
+}
+
+var profileDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a profile with its secrets, wallet and history",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if !profileDeleteForce && !confirm(fmt.Sprintf("Delete profile %q including its wallet and secrets? This cannot be undone.", name)) {
+			fmt.Println("⚪ Aborted.")
+			return withExitCode(exitError, nil)
+		}
+
+		err := sidecar.DeleteProfile(cmd.Context(), name)
+		if errors.Is(err, sidecar.ErrProfileNotFound) {
+			fmt.Printf("⚪ Profile %q does not exist.\n", name)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🗑️  Deleted profile %q\n", name)
+		return nil
+	},
+}
+
+func init() {
+	profileCreateCmd.Flags().StringVar(&profileNetwork, "network", "", "Network setting for the new profile (devnet, mainnet-beta)")
+	profileDeleteCmd.Flags().BoolVarP(&profileDeleteForce, "force", "f", false, "Delete without asking")
+
+	profileCmd.AddCommand(profileListCmd, profileCreateCmd, profileUseCmd, profileDeleteCmd)
+	rootCmd.AddCommand(profileCmd)
+}
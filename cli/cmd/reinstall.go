@@ -10,7 +10,7 @@ import (
 var reinstallCmd = &cobra.Command{
 	Use:   "reinstall",
 	Short: "Wipe all data and perform a fresh installation",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("⚠️  Preparing for Reinstallation...")
 		
 homeDir, _ := os.UserHomeDir()
@@ -18,15 +18,14 @@ homeDir, _ := os.UserHomeDir()
 
 		fmt.Printf("🗑️  Wiping application data at %s...\n", prismDir)
 		if err := os.RemoveAll(prismDir); err != nil {
-			fmt.Printf("❌ Failed to wipe data: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to wipe data: %w", err)
 		}
 
 		fmt.Println("✅ Data wiped. Starting fresh installation...")
 		
 		// Re-use update logic by calling the update command's Run function
 		// or simply triggering the same shell logic
-		updateCmd.Run(cmd, args)
+		return updateCmd.RunE(cmd, args)
 	},
 }
 
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
 	"github.com/spf13/cobra"
 )
 
@@ -11,15 +16,39 @@ var rootCmd = &cobra.Command{
 	Use:   "shadowprism",
 	Short: "ShadowPrism is a privacy-first liquidity aggregator for Solana",
 	Long:  `A secure sidecar that routes Solana transactions through privacy protocols.`,
+
+	PersistentPreRunE: loadSettings,
+	// Execute prints errors itself, once, and usage only for bad
+	// invocations rather than for every failure.
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	// Ctrl-C cancels the command context so in-flight core calls abort.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	markUsageErrors(rootCmd)
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return invocationError(err)
+	})
+
+	// Errors go to stderr so stdout stays parseable with --output.
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	if err != nil && err.Error() != "" {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
 	}
+	var coded *codedError
+	if errors.As(err, &coded) && coded.showUsage {
+		fmt.Fprint(os.Stderr, cmd.UsageString())
+	}
+	stop()
+	os.Exit(exitCode(err))
 }
 
 func init() {
-	// Root flags can be defined here
-}
\ No newline at end of file
+	rootCmd.Version = sidecar.Version
+	rootCmd.PersistentFlags().BoolVar(&keepCore, "keep-core", false, "Leave a core started by this command running in the background")
+	rootCmd.PersistentFlags().StringVar(&sidecar.Profile, "profile", "", "Profile to use (default from `shadowprism profile use`, or $SHADOWPRISM_PROFILE)")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Settings file to use (default ~/.shadowprism/config.yaml, or $SHADOWPRISM_CONFIG)")
+}
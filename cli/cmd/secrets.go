@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var secretReveal bool
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage encrypted secrets such as API keys and RPC URLs",
+}
+
+var secretsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored secrets without decrypting them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
+		if err != nil {
+			return err
+		}
+		secrets, err := cm.ListSecrets()
+		if err != nil {
+			return err
+		}
+		rows := make([]secretRow, len(secrets))
+		for i, s := range secrets {
+			rows[i] = secretRow{Name: s.Name, CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt, RevealedAt: s.RevealedAt}
+		}
+
+		return render(rows, func() {
+			if len(rows) == 0 {
+				fmt.Println("⚪ No secrets stored.")
+				return
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tVALUE\tUPDATED\tLAST REVEALED")
+			for _, s := range rows {
+				revealed := "never"
+				if s.RevealedAt != nil {
+					revealed = formatAuditTime(*s.RevealedAt)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, strings.Repeat("•", 8), formatAuditTime(s.UpdatedAt), revealed)
+			}
+			w.Flush()
+		})
+	},
+}
+
+// secretRow is one secret in the machine-readable output of secrets list.
+// Values are never included.
+type secretRow struct {
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	RevealedAt *time.Time `json:"revealed_at"`
+}
+
+var secretsSetCmd = &cobra.Command{
+	Use:   "set [name] [value]",
+	Short: "Encrypt and store a secret",
+	Long: `Encrypt and store a secret.
+
+Leave out the value to type it at a hidden prompt or pipe it on stdin, which
+keeps it out of your shell history.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := sidecar.ValidateSecretName(name); err != nil {
+			return usageError(err)
+		}
+
+		var value string
+		if len(args) == 2 {
+			value = args[1]
+		} else {
+			var err error
+			if value, err = readSecretValue(name); err != nil {
+				return err
+			}
+		}
+		if value == "" {
+			return usageError(errors.New("refusing to store an empty secret"))
+		}
+
+		cm, err := configManager()
+		if err != nil {
+			return err
+		}
+		replaced := cm.SecretExists(name)
+		if err := cm.SaveSecret(name, value); err != nil {
+			return fmt.Errorf("failed to save secret: %w", err)
+		}
+		if replaced {
+			fmt.Printf("✅ Updated %s\n", name)
+		} else {
+			fmt.Printf("✅ Stored %s\n", name)
+		}
+		return nil
+	},
+}
+
+var secretsGetCmd = &cobra.Command{
+	Use:   "get [name]",
+	Short: "Show a secret, redacted unless --reveal is given",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
+		if err != nil {
+			return err
+		}
+
+		var value string
+		if secretReveal {
+			value, err = cm.RevealSecret(args[0])
+		} else {
+			value, err = cm.LoadSecret(args[0])
+			if errors.Is(err, os.ErrNotExist) {
+				err = fmt.Errorf("%w: %s", sidecar.ErrSecretNotFound, args[0])
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if secretReveal {
+			fmt.Println(value)
+		} else {
+			fmt.Println(sidecar.RedactSecret(value))
+		}
+		return nil
+	},
+}
+
+var secretsDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a stored secret",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
+		if err != nil {
+			return err
+		}
+		if err := cm.DeleteSecret(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("🗑️  Deleted %s\n", args[0])
+		return nil
+	},
+}
+
+var secretsRotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Re-encrypt every secret under a fresh key",
+	Long: `Re-encrypt every secret under a fresh key from the current key source:
+a new passphrase and salt, or a new random keyring key. See
+` + "`shadowprism config key-source`" + ` to change the source instead.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := configManager()
+		if err != nil {
+			return err
+		}
+		n, err := cm.RotateKey()
+		if err != nil {
+			return fmt.Errorf("failed to rotate key: %w", err)
+		}
+		fmt.Printf("🔄 Re-encrypted %d secret(s) under a new key.\n", n)
+		return nil
+	},
+}
+
+// readSecretValue prompts without echo on a terminal, or reads one line
+// from piped stdin.
+func readSecretValue(name string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "🔑 Value for %s: ", name)
+		value, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(value), err
+	}
+
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(value, "\r\n"), nil
+}
+
+func formatAuditTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func init() {
+	secretsGetCmd.Flags().BoolVar(&secretReveal, "reveal", false, "Print the decrypted value (recorded in the audit log)")
+
+	secretsCmd.AddCommand(secretsListCmd, secretsSetCmd, secretsGetCmd, secretsDeleteCmd, secretsRotateKeyCmd)
+	configCmd.AddCommand(secretsCmd)
+}
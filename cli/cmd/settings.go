@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// settingAnnotation marks a flag whose default comes from a settings key.
+const settingAnnotation = "shadowprism_setting"
+
+var (
+	// configPath is the --config flag.
+	configPath string
+	// settings is loaded before every command except `config` ones, which
+	// must keep working while the file is invalid.
+	settings *sidecar.Settings
+)
+
+// bindSetting makes flag fall back to the settings key when it isn't given
+// on the command line, giving flag > env > file > default precedence.
+func bindSetting(flags *pflag.FlagSet, flag, key string) {
+	flags.SetAnnotation(flag, settingAnnotation, []string{key})
+}
+
+// loadSettings reads the settings file and applies it to every bound flag
+// the user didn't set.
+func loadSettings(cmd *cobra.Command, args []string) error {
+	sidecar.SettingsFile = configPath
+
+	// Reject a bad --output before a command acts, not after.
+	if err := checkOutputFormat(); err != nil {
+		return err
+	}
+
+	cm, err := configManager()
+	if err != nil {
+		return err
+	}
+	if settings, err = cm.LoadSettings(); err != nil {
+		return err
+	}
+
+	var applyErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		keys := f.Annotations[settingAnnotation]
+		if f.Changed || len(keys) == 0 || applyErr != nil {
+			return
+		}
+		applyErr = f.Value.Set(settings.Get(keys[0]))
+	})
+	return applyErr
+}
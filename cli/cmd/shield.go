@@ -1,12 +1,13 @@
 package cmd
 
 import (
-	"context"
+	"bufio"
 	"fmt"
-	"strconv"
-	"time"
+	"os"
+	"strings"
 
-	"github.com/nathfavour/shadowprism/cli/internal/agent"
+	"github.com/nathfavour/shadowprism/cli/api"
+	"github.com/nathfavour/shadowprism/cli/internal/amount"
 	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
 	"github.com/spf13/cobra"
 )
@@ -19,46 +20,104 @@ var (
 var shieldCmd = &cobra.Command{
 	Use:   "shield [amount] [destination]",
 	Short: "Anonymize SOL by routing through a privacy provider",
-	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		amount, err := strconv.ParseUint(args[0], 10, 64)
+	Long: `Anonymize SOL by routing it through a privacy provider.
+
+The amount takes a unit, like 0.5sol or "1.25 SOL", or is a share of
+your SOL balance, like 10%. A bare decimal like 0.5 is SOL; a bare whole
+number is in lamports. The unit may also be its own argument, as in
+"shield 1 SOL @treasury".
+
+The destination is a Solana address or an @alias from the address book.`,
+	Args: cobra.RangeArgs(2, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input, target := args[0], args[1]
+		if len(args) == 3 {
+			input, target = args[0]+" "+args[1], args[2]
+		}
+		amt, err := amount.Parse(input, amount.Options{Token: "SOL"})
+		if err != nil {
+			return usageError(err)
+		}
+		dest, err := resolveAddress(target)
 		if err != nil {
-			fmt.Println("❌ Invalid amount. Must be lamports (uint64)")
-			return
+			return err
 		}
-		dest := args[1]
 
-		pa := agent.NewPrismAgent()
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+		sess, err := connectCore(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer sess.Close()
+		client := sess.Client
 
-		cm, _ := sidecar.NewConfigManager()
-		socketPath := cm.GetSocketPath()
-		client := sidecar.NewCoreClient(socketPath, "dev-token-123")
+		lamports, err := client.ResolveAmount(cmd.Context(), amt)
+		if err != nil {
+			return err
+		}
+
+		progress("🕵️  Initiating Privacy Shield for %s...\n", amount.SOL(lamports))
 
-		fmt.Printf("🕵️  Initiating Privacy Shield for %d lamports...\n", amount)
-		
-		res, err := client.Shield(amount, dest, shieldStrategy, shieldForce)
+		res, err := client.Shield(cmd.Context(), lamports, dest, shieldStrategy, shieldForce)
+		if sidecar.IsKind(err, sidecar.KindComplianceBlocked) && !shieldForce {
+			fmt.Fprintln(os.Stderr, "🚨 Range Protocol flagged this destination as high risk.")
+			if confirm("Override the compliance firewall and shield anyway?") {
+				res, err = client.Shield(cmd.Context(), lamports, dest, shieldStrategy, true)
+			}
+		}
 		if err != nil {
-			fmt.Printf("❌ %v\n", err)
-			return
+			return err
 		}
 
-		fmt.Printf("✅ Shield Success!\n")
-		fmt.Printf("🔗 TX: %s\n", res["tx_hash"])
-		fmt.Printf("🛡️  Provider: %v\n", res["provider"])
-		if note, ok := res["note"].(string); ok && note != "" {
-			fmt.Printf("🔑 Note: %s (Stored in local DB)\n", note)
+		result := shieldResult{
+			TaskID:         res.TaskID,
+			Status:         res.Status,
+			TxHash:         res.TxHash,
+			Provider:       res.Provider,
+			Note:           api.Deref(res.Note),
+			AmountLamports: lamports,
+			Destination:    dest,
+			Strategy:       shieldStrategy,
 		}
+		return render(result, func() {
+			fmt.Printf("✅ Shield Success!\n")
+			if res.TaskID != "" {
+				fmt.Printf("🆔 Task: %s (track with `shadowprism task %s --wait`)\n", res.TaskID, res.TaskID)
+			}
+			fmt.Printf("🔗 TX: %s\n", res.TxHash)
+			fmt.Printf("🛡️  Provider: %s\n", res.Provider)
+			if result.Note != "" {
+				fmt.Printf("🔑 Note: %s (Stored in local DB)\n", result.Note)
+			}
 
-		// Agent recommendation
-		resp, _ := pa.Talk(ctx, fmt.Sprintf("The user just shielded %d lamports to %s. Give a professional confirmation and a small tip.", amount, dest))
-		pa.DisplayResponse(resp)
+			// Agent recommendation
+			agentComment(cmd.Context(), fmt.Sprintf("The user just shielded %s to %s. Give a professional confirmation and a small tip.", amount.SOL(lamports), dest))
+		})
 	},
 }
 
+// shieldResult is the machine-readable output of shield.
+type shieldResult struct {
+	TaskID         string `json:"task_id"`
+	Status         string `json:"status"`
+	TxHash         string `json:"tx_hash"`
+	Provider       string `json:"provider"`
+	Note           string `json:"note"`
+	AmountLamports uint64 `json:"amount_lamports"`
+	Destination    string `json:"destination"`
+	Strategy       string `json:"strategy"`
+}
+
 func init() {
-	shieldCmd.Flags().StringVarP(&shieldStrategy, "strategy", "s", "privacy_cash", "Privacy strategy to use (privacy_cash, radr_p2p)")
+	shieldCmd.Flags().StringVarP(&shieldStrategy, "strategy", "s", "privacy_cash", "Privacy strategy to use (privacy_cash, radr_p2p, mix_standard)")
+	bindSetting(shieldCmd.Flags(), "strategy", "shield.strategy")
 	shieldCmd.Flags().BoolVarP(&shieldForce, "force", "f", false, "Force transaction even if destination is high-risk")
 	rootCmd.AddCommand(shieldCmd)
 }
+
+// confirm asks a yes/no question on stdin, defaulting to no.
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
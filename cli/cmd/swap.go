@@ -1,13 +1,10 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
-	"strconv"
-	"time"
+	"strings"
 
-	"github.com/nathfavour/shadowprism/cli/internal/agent"
-	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
+	"github.com/nathfavour/shadowprism/cli/internal/amount"
 	"github.com/spf13/cobra"
 )
 
@@ -19,44 +16,75 @@ var (
 var swapCmd = &cobra.Command{
 	Use:   "swap [amount]",
 	Short: "Private token exchange via SilentSwap",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		amount, err := strconv.ParseUint(args[0], 10, 64)
+	Long: `Privately exchange tokens via SilentSwap.
+
+The amount is in the --from token and takes a unit, like 1.5sol or
+"25 USDC". A bare decimal like 1.5 is whole tokens; a bare whole number
+is in the token's base units. When swapping from SOL it may also be a
+share of your balance, like 10%.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amt, err := amount.Parse(args[0], amount.Options{Token: fromToken})
 		if err != nil {
-			fmt.Println("❌ Invalid amount. Must be lamports (uint64)")
-			return
+			return usageError(err)
+		}
+		if amt.IsPercent() && !strings.EqualFold(amt.Token, "SOL") {
+			return usageError(fmt.Errorf("%w, and only the SOL balance is known; give an exact %s amount", amount.ErrNeedsBalance, amt.Token))
 		}
 
-		pa := agent.NewPrismAgent()
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+		sess, err := connectCore(cmd.Context())
+		if err != nil {
+			return err
+		}
+		defer sess.Close()
+		client := sess.Client
 
-		cm, _ := sidecar.NewConfigManager()
-		socketPath := cm.GetSocketPath()
-		authToken := "dev-token-123" // In production, this is managed by the sidecar manager
+		units, err := client.ResolveAmount(cmd.Context(), amt)
+		if err != nil {
+			return err
+		}
 
-		client := sidecar.NewCoreClient(socketPath, authToken)
+		progress("🔄 Initiating Private Swap: %s -> %s...\n", amount.Format(units, fromToken), toToken)
 
-		fmt.Printf("🔄 Initiating Private Swap: %d %s -> %s...\n", amount, fromToken, toToken)
-		
-		res, err := client.Swap(amount, fromToken, toToken)
+		res, err := client.Swap(cmd.Context(), units, fromToken, toToken)
 		if err != nil {
-			fmt.Printf("❌ Swap failed: %v\n", err)
-			return
+			return err
 		}
 
-		fmt.Printf("✅ Swap Confirmed!\n")
-		fmt.Printf("🔗 TX: %s\n", res["tx_hash"])
-		fmt.Printf("💰 Received: %v %s\n", res["to_amount"], toToken)
+		result := swapResult{
+			Status:     res.Status,
+			TxHash:     res.TxHash,
+			FromToken:  fromToken,
+			FromAmount: units,
+			ToToken:    toToken,
+			ToAmount:   res.ToAmount,
+		}
+		return render(result, func() {
+			fmt.Printf("✅ Swap Confirmed!\n")
+			fmt.Printf("🔗 TX: %s\n", res.TxHash)
+			fmt.Printf("💰 Received: %s\n", amount.Format(res.ToAmount, toToken))
 
-		// Agent feedback
-		resp, _ := pa.Talk(ctx, fmt.Sprintf("The user just swapped %d %s for %s. Mention the slippage or privacy benefits.", amount, fromToken, toToken))
-		pa.DisplayResponse(resp)
+			// Agent feedback
+			agentComment(cmd.Context(), fmt.Sprintf("The user just swapped %s for %s. Mention the slippage or privacy benefits.", amount.Format(units, fromToken), toToken))
+		})
 	},
 }
 
+// swapResult is the machine-readable output of swap. Amounts are in base
+// units of their token.
+type swapResult struct {
+	Status     string `json:"status"`
+	TxHash     string `json:"tx_hash"`
+	FromToken  string `json:"from_token"`
+	FromAmount uint64 `json:"from_amount"`
+	ToToken    string `json:"to_token"`
+	ToAmount   uint64 `json:"to_amount"`
+}
+
 func init() {
 	swapCmd.Flags().StringVar(&fromToken, "from", "SOL", "Token to swap from")
 	swapCmd.Flags().StringVar(&toToken, "to", "USDC", "Token to swap to")
+	bindSetting(swapCmd.Flags(), "from", "swap.from")
+	bindSetting(swapCmd.Flags(), "to", "swap.to")
 	rootCmd.AddCommand(swapCmd)
 }
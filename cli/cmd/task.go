@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/api"
+	"github.com/nathfavour/shadowprism/cli/internal/amount"
+	"github.com/spf13/cobra"
+)
+
+var (
+	taskWait    bool
+	taskTimeout time.Duration
+)
+
+var taskCmd = &cobra.Command{
+	Use:   "task [id]",
+	Short: "Show the status of a shield task",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id := args[0]
+
+		sess, err := connectCore(cmd.Context())
+		if err != nil {
+			return err
+		}
+		client := sess.Client
+
+		var rec *api.TransactionRecord
+		if taskWait {
+			progress("⏳ Waiting for task %s to settle...\n", id)
+			ctx, cancel := context.WithTimeout(cmd.Context(), taskTimeout)
+			defer cancel()
+			rec, err = client.WaitForTask(ctx, id)
+		} else {
+			rec, err = client.GetTask(cmd.Context(), id)
+		}
+
+		sess.Close()
+
+		if rec != nil {
+			if err := render(rec, func() { printTask(rec) }); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if rec.Status == api.StatusFailed {
+			return withExitCode(exitProvider, fmt.Errorf("task %s failed", rec.ID))
+		}
+		return nil
+	},
+}
+
+func printTask(rec *api.TransactionRecord) {
+	fmt.Printf("%s Task %s: %s\n", statusEmoji(rec.Status), rec.ID, rec.Status)
+	fmt.Printf("💰 Amount: %s\n", amount.SOL(rec.AmountLamports))
+	fmt.Printf("📍 Destination: %s\n", rec.Destination)
+	fmt.Printf("🛡️  Provider: %s\n", rec.Provider)
+	if hash := api.Deref(rec.TxHash); hash != "" {
+		fmt.Printf("🔗 TX: %s\n", hash)
+	}
+	if note := api.Deref(rec.Note); note != "" {
+		fmt.Printf("🔑 Note: %s\n", note)
+	}
+	fmt.Printf("🕒 Created: %s\n", rec.CreatedAt.Local().Format(time.RFC1123))
+}
+
+func init() {
+	taskCmd.Flags().BoolVarP(&taskWait, "wait", "w", false, "Block until the task is Confirmed or Failed")
+	taskCmd.Flags().DurationVar(&taskTimeout, "timeout", 5*time.Minute, "Maximum time to wait with --wait")
+	bindSetting(taskCmd.Flags(), "timeout", "task.wait_timeout")
+	rootCmd.AddCommand(taskCmd)
+}
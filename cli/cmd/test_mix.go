@@ -2,52 +2,54 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
 	"github.com/spf13/cobra"
 )
 
 var testMixCmd = &cobra.Command{
-	Use:   "test-mix",
+	Use:   "test-mix [destination]",
 	Short: "Send a test shielding request to the core engine",
-	Run: func(cmd *cobra.Command, args []string) {
-		token := "dev-token-123"
-		cm, err := sidecar.NewConfigManager()
+	Long: `Send a test shielding request of 1 SOL to the core engine.
+
+The destination is a Solana address or an @alias from the address book;
+without one the wallet shields to itself.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var dest string
+		if len(args) == 1 {
+			var err error
+			if dest, err = resolveAddress(args[0]); err != nil {
+				return err
+			}
+		}
+
+		sess, err := connectCore(cmd.Context())
 		if err != nil {
-			fmt.Printf("❌ Error: %v\n", err)
-			os.Exit(1)
+			return err
 		}
-		socketPath := cm.GetSocketPath()
-		client := sidecar.NewCoreClient(socketPath, token)
-
-		fmt.Println("🧪 Sending test shielding request via UDS...")
-		
-		payload := map[string]interface{}{
-			"amount_lamports":  1000000000,
-			"destination_addr": "BuX...7z",
-			"strategy":         "mix_standard",
+		defer sess.Close()
+		client := sess.Client
+
+		if dest == "" {
+			bal, err := client.GetBalance(cmd.Context())
+			if err != nil {
+				return err
+			}
+			dest = bal.Address
 		}
 
-		var result map[string]interface{}
-		resp, err := client.Http.R().
-			SetBody(payload).
-			SetResult(&result).
-			Post("/v1/shield")
+		progress("🧪 Sending test shielding request via UDS to %s...\n", dest)
 
+		result, err := client.Shield(cmd.Context(), 1000000000, dest, "mix_standard", false)
 		if err != nil {
-			fmt.Printf("❌ Request failed: %v\n", err)
-			os.Exit(1)
-		}
-
-		if resp.IsError() {
-			fmt.Printf("❌ Core returned error (%d): %s\n", resp.StatusCode(), resp.String())
-			os.Exit(1)
+			return err
 		}
 
-		fmt.Println("✅ Shielding Success!")
-		fmt.Printf("🔗 Transaction Hash: %v\n", result["tx_hash"])
-		fmt.Printf("🛡️ Provider Used: %v\n", result["provider"])
+		return render(result, func() {
+			fmt.Println("✅ Shielding Success!")
+			fmt.Printf("🔗 Transaction Hash: %s\n", result.TxHash)
+			fmt.Printf("🛡️ Provider Used: %s\n", result.Provider)
+		})
 	},
 }
 
@@ -11,7 +11,7 @@ import (
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update ShadowPrism to the latest version",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("🔄 Updating ShadowPrism...")
 
 		// Construct the installation command
@@ -28,11 +28,11 @@ var updateCmd = &cobra.Command{
 		shellCmd.Stderr = os.Stderr
 
 		if err := shellCmd.Run(); err != nil {
-			fmt.Printf("❌ Update failed: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("update failed: %w", err)
 		}
 
 		fmt.Println("✨ ShadowPrism updated successfully!")
+		return nil
 	},
 }
 
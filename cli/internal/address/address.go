@@ -0,0 +1,100 @@
+// Package address validates Solana public keys before they reach the core.
+// Base58 has no checksum, so a mistyped address decodes fine; the length and
+// curve checks here catch most typos and addresses that no wallet can sign for.
+package address
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Size is the length of a decoded public key.
+const Size = 32
+
+var (
+	ErrInvalid  = errors.New("invalid Solana address")
+	ErrOffCurve = errors.New("address is not on the ed25519 curve")
+)
+
+// Decode parses a base58 public key.
+func Decode(s string) ([Size]byte, error) {
+	var key [Size]byte
+	if len(s) < 32 || len(s) > 44 {
+		return key, fmt.Errorf("%w %q: must be 32 to 44 base58 characters, got %d", ErrInvalid, s, len(s))
+	}
+
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for i, r := range s {
+		d := strings.IndexRune(alphabet, r)
+		if d < 0 {
+			return key, fmt.Errorf("%w %q: %q at position %d is not base58 (0, O, I and l are never used)", ErrInvalid, s, r, i+1)
+		}
+		n.Mul(n, radix).Add(n, big.NewInt(int64(d)))
+	}
+
+	// Each leading '1' is a leading zero byte.
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+	b := n.Bytes()
+	if zeros+len(b) != Size {
+		return key, fmt.Errorf("%w %q: decodes to %d bytes, want %d", ErrInvalid, s, zeros+len(b), Size)
+	}
+	copy(key[zeros:], b)
+	return key, nil
+}
+
+// Validate checks that s is a well-formed public key that a wallet could own.
+// Program-derived addresses are off the curve and rejected, since funds sent
+// to them can only be moved by their program.
+func Validate(s string) error {
+	key, err := Decode(s)
+	if err != nil {
+		return err
+	}
+	if !IsOnCurve(key) {
+		return fmt.Errorf("%w: %s looks like a program-derived address", ErrOffCurve, s)
+	}
+	return nil
+}
+
+var (
+	fieldP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	// edwardsD is -121665/121666 mod p.
+	edwardsD = func() *big.Int {
+		d := new(big.Int).ModInverse(big.NewInt(121666), fieldP)
+		d.Mul(d, big.NewInt(-121665))
+		return d.Mod(d, fieldP)
+	}()
+	legendreExp = new(big.Int).Rsh(new(big.Int).Sub(fieldP, big.NewInt(1)), 1)
+)
+
+// IsOnCurve reports whether key is a compressed ed25519 point, matching the
+// runtime's is_on_curve: y is reduced mod p and x² = (y²-1)/(dy²+1) must have
+// a square root.
+func IsOnCurve(key [Size]byte) bool {
+	le := key
+	le[31] &= 0x7f
+	for i, j := 0, len(le)-1; i < j; i, j = i+1, j-1 {
+		le[i], le[j] = le[j], le[i]
+	}
+	y := new(big.Int).SetBytes(le[:])
+	y.Mod(y, fieldP)
+
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, fieldP)
+	u := new(big.Int).Sub(y2, big.NewInt(1))
+	v := new(big.Int).Mul(edwardsD, y2)
+	v.Add(v, big.NewInt(1)).Mod(v, fieldP)
+
+	// v is never zero because d is not a square mod p.
+	x2 := new(big.Int).ModInverse(v, fieldP)
+	x2.Mul(x2, u).Mod(x2, fieldP)
+	if x2.Sign() == 0 {
+		return true
+	}
+	return new(big.Int).Exp(x2, legendreExp, fieldP).Cmp(big.NewInt(1)) == 0
+}
@@ -2,10 +2,8 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
-	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -15,43 +13,65 @@ var (
 	hintStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Italic(true)
 )
 
+// persona keeps replies short and in character on every backend.
+const persona = "You are ShadowPrism AI, a privacy-first assistant for Solana. " +
+	"Provide a very concise, professional, and slightly futuristic response (max 2 sentences)."
+
+// offlineReply stands in for an answer when no backend is available.
+const offlineReply = "I'm currently operating in offline mode. Install vibeaura or set agent.base_url to enable advanced AI insights."
+
 type PrismAgent struct {
-	VibePath string
+	Assistant Assistant
 }
 
-func NewPrismAgent() *PrismAgent {
-	// Fallback to searching in common paths
-	path := "/home/nathfavour/.local/bin/vibeaura"
-	return &PrismAgent{VibePath: path}
+func NewPrismAgent(a Assistant) *PrismAgent {
+	return &PrismAgent{Assistant: a}
 }
 
 func (a *PrismAgent) Talk(ctx context.Context, prompt string) (string, error) {
-	// Check if vibeaura exists
-	if _, err := os.Stat(a.VibePath); os.IsNotExist(err) {
-		return "I'm currently operating in offline mode. Please install the 'vibeaura' agent to enable advanced AI insights.", nil
-	}
-
-	// We want concise, conversational responses
-	fullPrompt := "You are ShadowPrism AI, a privacy-first assistant for Solana. " +
-		"Provide a very concise, professional, and slightly futuristic response (max 2 sentences). " +
-		"Context: " + prompt
-
-	args := []string{"direct", "--non-interactive"}
-	cmd := exec.CommandContext(ctx, a.VibePath, args...)
-	cmd.Stdin = strings.NewReader(fullPrompt)
+	return a.talk(ctx, prompt, nil)
+}
 
-	output, err := cmd.CombinedOutput()
+func (a *PrismAgent) talk(ctx context.Context, prompt string, emit func(string)) (string, error) {
+	reply, err := complete(ctx, a.Assistant, Request{System: persona, Prompt: prompt}, emit)
+	if errors.Is(err, ErrUnavailable) {
+		return emitOffline(emit), nil
+	}
 	if err != nil {
 		return "", fmt.Errorf("agent error: %w", err)
 	}
+	return reply.Text, nil
+}
 
-	return strings.TrimSpace(string(output)), nil
+// emitOffline returns offlineReply, emitting it when the caller streams.
+func emitOffline(emit func(string)) string {
+	if emit != nil {
+		emit(offlineReply)
+	}
+	return offlineReply
 }
 
 func (a *PrismAgent) DisplayResponse(text string) {
 	fmt.Printf("\n%s %s\n", agentStyle.Render("🤖 ShadowPrism:"), text)
 }
 
+// DisplayStream prints a reply as it is written and returns it once
+// complete. Nothing is printed for a reply that fails before its first word.
+func (a *PrismAgent) DisplayStream(s *Stream) (string, error) {
+	started := false
+	for delta := range s.Deltas {
+		if !started {
+			fmt.Printf("\n%s ", agentStyle.Render("🤖 ShadowPrism:"))
+			started = true
+		}
+		fmt.Print(delta)
+	}
+	if started {
+		fmt.Println()
+	}
+	return s.Wait()
+}
+
 func (a *PrismAgent) DisplayHint(hint string) {
 	fmt.Printf("\n%s %s\n", hintStyle.Render("💡 Hint:"), hint)
 }
@@ -64,4 +84,4 @@ func (a *PrismAgent) GetHint(ctx context.Context, action string) {
 			a.DisplayHint(h)
 		}
 	}()
-}
\ No newline at end of file
+}
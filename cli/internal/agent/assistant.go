@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnavailable means the backend isn't installed or configured. Talk
+// answers with an offline notice instead of failing.
+var ErrUnavailable = errors.New("AI backend unavailable")
+
+// Backend names accepted by New.
+const (
+	BackendAuto     = "auto"
+	BackendVibeAura = "vibeaura"
+	BackendOpenAI   = "openai"
+	BackendStub     = "stub"
+)
+
+// Backends lists the backend names accepted by New.
+var Backends = []string{BackendAuto, BackendVibeAura, BackendOpenAI, BackendStub}
+
+// Request is one prompt for an assistant. System sets the persona and
+// response style; Prompt is what the user or a command asked. History holds
+// earlier turns of the conversation, oldest first.
+type Request struct {
+	System  string
+	History []Message
+	Prompt  string
+	// Tools the model may call instead of answering. Steps holds the calls
+	// it made earlier in this turn, each followed by its result.
+	Tools []ToolSpec
+	Steps []Message
+}
+
+// Reply is the model's answer: text, tool calls to run, or both.
+type Reply struct {
+	Text  string
+	Calls []ToolCall
+}
+
+// Assistant is a language model backend.
+type Assistant interface {
+	// Name identifies the backend in messages, e.g. "vibeaura".
+	Name() string
+	// Complete returns the model's reply to req.
+	Complete(ctx context.Context, req Request) (Reply, error)
+}
+
+// Config selects and configures a backend.
+type Config struct {
+	// Backend is one of Backends. Auto picks vibeaura when it can be found,
+	// then an OpenAI-compatible endpoint when BaseURL is set.
+	Backend string
+	// VibePath is the vibeaura binary; empty searches $PATH and
+	// ~/.local/bin.
+	VibePath string
+	// BaseURL is an OpenAI-compatible API root such as
+	// http://localhost:11434/v1.
+	BaseURL string
+	Model   string
+	APIKey  string
+	// Privacy is one of PrivacyModes; empty means PrivacyRedact.
+	Privacy string
+}
+
+// New returns the backend cfg selects, behind the redaction cfg.Privacy
+// asks for. An auto config with nothing available yields a backend that
+// always reports ErrUnavailable.
+func New(cfg Config) (Assistant, error) {
+	a, err := newBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.Privacy {
+	case PrivacyOff:
+		return a, nil
+	case PrivacyRedact, "":
+		return &Private{Assistant: a}, nil
+	case PrivacyStrict:
+		return &Private{Assistant: a, Strict: true}, nil
+	}
+	return nil, fmt.Errorf("unknown privacy mode %q", cfg.Privacy)
+}
+
+func newBackend(cfg Config) (Assistant, error) {
+	switch cfg.Backend {
+	case BackendVibeAura:
+		return &VibeAura{Path: cfg.VibePath}, nil
+	case BackendOpenAI:
+		if cfg.BaseURL == "" {
+			return nil, errors.New("the openai backend needs agent.base_url")
+		}
+		return &OpenAI{BaseURL: cfg.BaseURL, Model: cfg.Model, APIKey: cfg.APIKey}, nil
+	case BackendStub:
+		return &Stub{}, nil
+	case BackendAuto, "":
+		if v := (&VibeAura{Path: cfg.VibePath}); v.find() != "" {
+			return v, nil
+		}
+		if cfg.BaseURL != "" {
+			return &OpenAI{BaseURL: cfg.BaseURL, Model: cfg.Model, APIKey: cfg.APIKey}, nil
+		}
+		return offline{}, nil
+	}
+	return nil, fmt.Errorf("unknown AI backend %q", cfg.Backend)
+}
+
+// offline is the auto backend when nothing is installed.
+type offline struct{}
+
+func (offline) Name() string { return "offline" }
+
+func (offline) Complete(context.Context, Request) (Reply, error) {
+	return Reply{}, ErrUnavailable
+}
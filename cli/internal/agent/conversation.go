@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Message roles.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is one turn of a conversation. Tool calls and their results only
+// appear in Request.Steps; conversations remember the text exchange.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// ToolCalls are the calls an assistant message asked for.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID links a tool message to the call it answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// Conversation is the bounded history of one chat session. It is safe for
+// concurrent use.
+type Conversation struct {
+	mu       sync.Mutex
+	budget   int
+	messages []Message
+}
+
+// NewConversation starts a conversation that keeps at most budget estimated
+// tokens of history, seeded with history from an earlier session. A zero
+// budget keeps nothing, so every message stands alone.
+func NewConversation(budget int, history []Message) *Conversation {
+	c := &Conversation{budget: budget, messages: append([]Message(nil), history...)}
+	c.trim()
+	return c
+}
+
+// History returns a copy of the remembered messages, oldest first.
+func (c *Conversation) History() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.messages...)
+}
+
+// Len is the number of remembered messages.
+func (c *Conversation) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.messages)
+}
+
+// Append records an exchange and forgets the oldest ones past the budget.
+func (c *Conversation) Append(prompt, reply string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages,
+		Message{Role: RoleUser, Content: prompt},
+		Message{Role: RoleAssistant, Content: reply})
+	c.trim()
+}
+
+// Reset forgets everything.
+func (c *Conversation) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = nil
+}
+
+// trim drops whole exchanges from the front until the history fits the
+// budget, so the model never sees a reply without its question.
+func (c *Conversation) trim() {
+	total := 0
+	for _, m := range c.messages {
+		total += EstimateTokens(m.Content)
+	}
+	for total > c.budget && len(c.messages) > 0 {
+		n := min(2, len(c.messages))
+		for _, m := range c.messages[:n] {
+			total -= EstimateTokens(m.Content)
+		}
+		c.messages = c.messages[n:]
+	}
+}
+
+// EstimateTokens approximates how many tokens s costs, at about four bytes
+// per token for English text. Close enough to budget history without a
+// model-specific tokenizer.
+func EstimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// Chat answers input in the context of conv and records the exchange. An
+// offline reply is returned but not remembered.
+func (a *PrismAgent) Chat(ctx context.Context, conv *Conversation, input string) (string, error) {
+	return a.chat(ctx, conv, input, nil)
+}
+
+func (a *PrismAgent) chat(ctx context.Context, conv *Conversation, input string, emit func(string)) (string, error) {
+	reply, err := complete(ctx, a.Assistant, Request{System: persona, History: conv.History(), Prompt: input}, emit)
+	if errors.Is(err, ErrUnavailable) {
+		return emitOffline(emit), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("agent error: %w", err)
+	}
+	conv.Append(input, reply.Text)
+	return reply.Text, nil
+}
@@ -0,0 +1,437 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/api"
+	"github.com/nathfavour/shadowprism/cli/internal/amount"
+	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
+)
+
+// CoreFunc returns a client for the core, connecting on first use so chats
+// that never call a tool never start one.
+type CoreFunc func(ctx context.Context) (*sidecar.CoreClient, error)
+
+// Strategies the shield tool accepts.
+var strategies = []string{"privacy_cash", "radr_p2p", "mix_standard"}
+
+// CoreTools exposes core operations to the model. Destinations resolve
+// through book, and shield uses strategy unless the model picks one.
+func CoreTools(core CoreFunc, book *sidecar.AddressBook, strategy string) []Tool {
+	return []Tool{
+		{
+			ToolSpec: ToolSpec{Name: "status", Description: "Check whether the core engine is healthy.", Parameters: schema(nil)},
+			Prepare: readOnly("status", "Check core status", func(ctx context.Context, c *sidecar.CoreClient) (any, error) {
+				return c.GetStatus(ctx)
+			}, core),
+		},
+		{
+			ToolSpec: ToolSpec{Name: "balance", Description: "Get the SOL balance of the ShadowPrism wallet.", Parameters: schema(nil)},
+			Prepare: readOnly("balance", "Check wallet balance", func(ctx context.Context, c *sidecar.CoreClient) (any, error) {
+				bal, err := c.GetBalance(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return struct {
+					Address string `json:"address"`
+					Balance string `json:"balance"`
+				}{bal.Address, amount.SOL(bal.Lamports)}, nil
+			}, core),
+		},
+		{
+			ToolSpec: ToolSpec{Name: "market", Description: "Get the current SOL price in USD.", Parameters: schema(nil)},
+			Prepare: readOnly("market", "Check market price", func(ctx context.Context, c *sidecar.CoreClient) (any, error) {
+				return c.GetMarket(ctx)
+			}, core),
+		},
+		{
+			ToolSpec: ToolSpec{
+				Name:        "history",
+				Description: "List recent transactions, newest first.",
+				Parameters: schema(map[string]any{
+					"status": enum("Only transactions with this status.", "Pending", "Broadcast", "Confirmed", "Failed"),
+					"limit":  map[string]any{"type": "integer", "description": "Maximum number of transactions, up to 20.", "minimum": 1, "maximum": 20},
+				}),
+			},
+			Prepare: prepareHistory(core),
+		},
+		{
+			ToolSpec: ToolSpec{
+				Name:        "get_task",
+				Description: "Get the status of a shield task by id.",
+				Parameters:  schema(map[string]any{"id": str("Task id returned by shield.")}, "id"),
+			},
+			Prepare: prepareGetTask(core),
+		},
+		{
+			ToolSpec: ToolSpec{Name: "list_contacts", Description: "List address book aliases and their notes, to find the @alias the user means.", Parameters: schema(nil)},
+			Prepare:  prepareContacts(book),
+		},
+		{
+			ToolSpec: ToolSpec{
+				Name:        "shield",
+				Description: "Propose shielding SOL to a destination through a privacy provider. Needs the user's approval.",
+				Parameters: schema(map[string]any{
+					"amount":      str(`SOL amount like "0.5 SOL", or a share of the balance like "50%".`),
+					"destination": str("Solana address or @alias."),
+					"strategy":    enum("Privacy strategy; radr_p2p for peer-to-peer.", strategies...),
+				}, "amount", "destination"),
+			},
+			Prepare: prepareShield(core, book, strategy),
+		},
+		{
+			ToolSpec: ToolSpec{
+				Name:        "swap",
+				Description: "Propose a private token swap. Needs the user's approval.",
+				Parameters: schema(map[string]any{
+					"amount": str(`Amount of the from token, like "25 USDC" or "10%" for SOL.`),
+					"from":   str("Token to sell, e.g. SOL."),
+					"to":     str("Token to buy, e.g. USDC."),
+				}, "amount", "from", "to"),
+			},
+			Prepare: prepareSwap(core),
+		},
+		{
+			ToolSpec: ToolSpec{
+				Name:        "pay",
+				Description: "Propose a private payment to a Starpay merchant. Needs the user's approval.",
+				Parameters: schema(map[string]any{
+					"merchant": str("Merchant Solana address or @alias."),
+					"amount":   str(`SOL amount like "0.5 SOL".`),
+				}, "merchant", "amount"),
+			},
+			Prepare: preparePay(core, book),
+		},
+	}
+}
+
+func schema(props map[string]any, required ...string) map[string]any {
+	if props == nil {
+		props = map[string]any{}
+	}
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func str(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+func enum(description string, values ...string) map[string]any {
+	return map[string]any{"type": "string", "description": description, "enum": values}
+}
+
+func decodeArgs(args json.RawMessage, v any) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// readOnly is a tool without arguments or side effects.
+func readOnly(name, summary string, fn func(context.Context, *sidecar.CoreClient) (any, error), core CoreFunc) func(context.Context, json.RawMessage) (*Action, error) {
+	return func(ctx context.Context, _ json.RawMessage) (*Action, error) {
+		return &Action{Tool: name, Summary: summary, run: func(ctx context.Context) (any, error) {
+			c, err := core(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return fn(ctx, c)
+		}}, nil
+	}
+}
+
+// txSummary is what the model sees of a transaction. Privacy notes can
+// withdraw shielded funds, so they never leave the machine.
+type txSummary struct {
+	ID          string         `json:"id"`
+	Amount      string         `json:"amount"`
+	Destination string         `json:"destination"`
+	Status      api.TaskStatus `json:"status"`
+	TxHash      string         `json:"tx_hash,omitempty"`
+	Provider    string         `json:"provider"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+func summarize(rec api.TransactionRecord) txSummary {
+	return txSummary{
+		ID:          rec.ID,
+		Amount:      amount.SOL(rec.AmountLamports),
+		Destination: rec.Destination,
+		Status:      rec.Status,
+		TxHash:      api.Deref(rec.TxHash),
+		Provider:    rec.Provider,
+		CreatedAt:   rec.CreatedAt,
+	}
+}
+
+func prepareHistory(core CoreFunc) func(context.Context, json.RawMessage) (*Action, error) {
+	return func(ctx context.Context, raw json.RawMessage) (*Action, error) {
+		var args struct {
+			Status string `json:"status"`
+			Limit  int    `json:"limit"`
+		}
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if args.Limit <= 0 || args.Limit > 20 {
+			args.Limit = 10
+		}
+		q := api.HistoryQuery{Status: api.TaskStatus(args.Status), Limit: args.Limit}
+
+		return &Action{Tool: "history", Summary: "List recent transactions", Request: q, run: func(ctx context.Context) (any, error) {
+			c, err := core(ctx)
+			if err != nil {
+				return nil, err
+			}
+			records, err := c.GetHistory(ctx, q)
+			if err != nil {
+				return nil, err
+			}
+			out := make([]txSummary, len(records))
+			for i, rec := range records {
+				out[i] = summarize(rec)
+			}
+			return out, nil
+		}}, nil
+	}
+}
+
+func prepareGetTask(core CoreFunc) func(context.Context, json.RawMessage) (*Action, error) {
+	return func(ctx context.Context, raw json.RawMessage) (*Action, error) {
+		var args struct {
+			ID string `json:"id"`
+		}
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if args.ID == "" {
+			return nil, fmt.Errorf("id is required")
+		}
+
+		return &Action{Tool: "get_task", Summary: "Check task " + args.ID, run: func(ctx context.Context) (any, error) {
+			c, err := core(ctx)
+			if err != nil {
+				return nil, err
+			}
+			rec, err := c.GetTask(ctx, args.ID)
+			if err != nil {
+				return nil, err
+			}
+			return summarize(*rec), nil
+		}}, nil
+	}
+}
+
+func prepareContacts(book *sidecar.AddressBook) func(context.Context, json.RawMessage) (*Action, error) {
+	return func(ctx context.Context, _ json.RawMessage) (*Action, error) {
+		return &Action{Tool: "list_contacts", Summary: "List contacts", run: func(ctx context.Context) (any, error) {
+			type contact struct {
+				Alias string `json:"alias"`
+				Note  string `json:"note,omitempty"`
+			}
+			out := []contact{}
+			for _, c := range book.Contacts() {
+				out = append(out, contact{Alias: "@" + c.Alias, Note: c.Note})
+			}
+			return out, nil
+		}}, nil
+	}
+}
+
+// resolveAmount parses a model-supplied amount of token, where a bare number
+// is whole tokens, and takes percentages of the wallet's SOL balance.
+func resolveAmount(ctx context.Context, core CoreFunc, input, token string) (uint64, error) {
+	a, err := amount.Parse(input, amount.Options{Token: token, Whole: true})
+	if err != nil {
+		return 0, err
+	}
+	if !a.IsPercent() || !strings.EqualFold(token, "SOL") {
+		return a.Resolve(nil)
+	}
+	c, err := core(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return c.ResolveAmount(ctx, a)
+}
+
+// describeAddress shows an alias alongside the address it resolved to.
+func describeAddress(input, addr string) string {
+	if strings.HasPrefix(input, "@") {
+		return fmt.Sprintf("%s (%s)", input, addr)
+	}
+	return addr
+}
+
+func prepareShield(core CoreFunc, book *sidecar.AddressBook, defaultStrategy string) func(context.Context, json.RawMessage) (*Action, error) {
+	return func(ctx context.Context, raw json.RawMessage) (*Action, error) {
+		var args struct {
+			Amount      string `json:"amount"`
+			Destination string `json:"destination"`
+			Strategy    string `json:"strategy"`
+		}
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		if args.Strategy == "" {
+			args.Strategy = defaultStrategy
+		}
+		if !contains(strategies, args.Strategy) {
+			return nil, fmt.Errorf("unknown strategy %q (want one of %s)", args.Strategy, strings.Join(strategies, ", "))
+		}
+		dest, err := book.Resolve(args.Destination)
+		if err != nil {
+			return nil, err
+		}
+		lamports, err := resolveAmount(ctx, core, args.Amount, "SOL")
+		if err != nil {
+			return nil, err
+		}
+
+		// The agent never overrides the compliance firewall.
+		req := api.ShieldRequest{AmountLamports: lamports, DestinationAddr: dest, Strategy: args.Strategy}
+		return &Action{
+			Tool:       "shield",
+			Summary:    fmt.Sprintf("Shield %s to %s via %s", amount.SOL(lamports), describeAddress(args.Destination, dest), args.Strategy),
+			Request:    req,
+			MovesValue: true,
+			run: func(ctx context.Context) (any, error) {
+				c, err := core(ctx)
+				if err != nil {
+					return nil, err
+				}
+				res, err := c.Shield(ctx, req.AmountLamports, req.DestinationAddr, req.Strategy, false)
+				if err != nil {
+					return nil, err
+				}
+				// The note stays in the core's store, out of the model's reach.
+				return struct {
+					TaskID     string `json:"task_id"`
+					Status     string `json:"status"`
+					TxHash     string `json:"tx_hash"`
+					Provider   string `json:"provider"`
+					NoteStored bool   `json:"note_stored"`
+				}{res.TaskID, res.Status, res.TxHash, res.Provider, res.Note != nil}, nil
+			},
+		}, nil
+	}
+}
+
+func prepareSwap(core CoreFunc) func(context.Context, json.RawMessage) (*Action, error) {
+	return func(ctx context.Context, raw json.RawMessage) (*Action, error) {
+		var args struct {
+			Amount string `json:"amount"`
+			From   string `json:"from"`
+			To     string `json:"to"`
+		}
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		from, ok := amount.Lookup(args.From)
+		if !ok {
+			return nil, fmt.Errorf("unknown token %q", args.From)
+		}
+		to, ok := amount.Lookup(args.To)
+		if !ok {
+			return nil, fmt.Errorf("unknown token %q", args.To)
+		}
+		units, err := resolveAmount(ctx, core, args.Amount, from.Symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		req := api.SwapRequest{AmountLamports: units, FromToken: from.Symbol, ToToken: to.Symbol}
+		return &Action{
+			Tool:       "swap",
+			Summary:    fmt.Sprintf("Swap %s for %s", amount.Format(units, from.Symbol), to.Symbol),
+			Request:    req,
+			MovesValue: true,
+			run: func(ctx context.Context) (any, error) {
+				c, err := core(ctx)
+				if err != nil {
+					return nil, err
+				}
+				res, err := c.Swap(ctx, req.AmountLamports, req.FromToken, req.ToToken)
+				if err != nil {
+					return nil, err
+				}
+				// Amounts carry their token so redaction recognises them.
+				return struct {
+					Status string `json:"status"`
+					TxHash string `json:"tx_hash"`
+					From   string `json:"from"`
+					To     string `json:"to"`
+				}{res.Status, res.TxHash, amount.Format(res.FromAmount, req.FromToken), amount.Format(res.ToAmount, req.ToToken)}, nil
+			},
+		}, nil
+	}
+}
+
+func preparePay(core CoreFunc, book *sidecar.AddressBook) func(context.Context, json.RawMessage) (*Action, error) {
+	return func(ctx context.Context, raw json.RawMessage) (*Action, error) {
+		var args struct {
+			Merchant string `json:"merchant"`
+			Amount   string `json:"amount"`
+		}
+		if err := decodeArgs(raw, &args); err != nil {
+			return nil, err
+		}
+		merchant, err := book.Resolve(args.Merchant)
+		if err != nil {
+			return nil, err
+		}
+		lamports, err := resolveAmount(ctx, core, args.Amount, "SOL")
+		if err != nil {
+			return nil, err
+		}
+
+		req := api.PayRequest{MerchantID: merchant, AmountLamports: lamports}
+		action := &Action{
+			Tool:       "pay",
+			Summary:    fmt.Sprintf("Pay %s to merchant %s", amount.SOL(lamports), describeAddress(args.Merchant, merchant)),
+			Request:    req,
+			MovesValue: true,
+		}
+		action.run = func(ctx context.Context) (any, error) {
+			c, err := core(ctx)
+			if err != nil {
+				return nil, err
+			}
+			res, err := c.Pay(ctx, req.AmountLamports, req.MerchantID)
+			if err != nil {
+				return nil, err
+			}
+			// The receipt ID proves the payment to the merchant, so like the
+			// shield note it goes to the user but never to the model.
+			if res.ReceiptID != "" {
+				action.Private = "Receipt ID: " + res.ReceiptID
+			}
+			return struct {
+				Status        string `json:"status"`
+				TxHash        string `json:"tx_hash"`
+				ReceiptIssued bool   `json:"receipt_issued"`
+			}{res.Status, res.TxHash, res.ReceiptID != ""}, nil
+		}
+		return action, nil
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// OpenAI talks to any server implementing the OpenAI chat completions API,
+// including local llama.cpp and ollama servers, so AI features work offline.
+type OpenAI struct {
+	// BaseURL is the API root, e.g. https://api.openai.com/v1 or
+	// http://localhost:8080/v1.
+	BaseURL string
+	// Model may be empty for servers that serve a single model.
+	Model  string
+	APIKey string
+	// HTTP defaults to http.DefaultClient.
+	HTTP *http.Client
+}
+
+func (o *OpenAI) Name() string { return BackendOpenAI }
+
+// The wire types follow the chat completions API, where tool arguments are
+// a JSON document encoded as a string.
+type wireMessage struct {
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	ToolCalls  []wireToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+type wireToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type wireTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description"`
+		Parameters  map[string]any `json:"parameters"`
+	} `json:"function"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model,omitempty"`
+	Messages []wireMessage `json:"messages"`
+	Tools    []wireTool    `json:"tools,omitempty"`
+	Stream   bool          `json:"stream,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message wireMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func toWire(m Message) wireMessage {
+	w := wireMessage{Role: m.Role, Content: m.Content, ToolCallID: m.ToolCallID}
+	for _, c := range m.ToolCalls {
+		var wc wireToolCall
+		wc.ID, wc.Type = c.ID, "function"
+		wc.Function.Name, wc.Function.Arguments = c.Name, string(c.Arguments)
+		w.ToolCalls = append(w.ToolCalls, wc)
+	}
+	return w
+}
+
+// streamChunk is one server-sent event of a streamed completion. Tool calls
+// arrive in fragments keyed by index; only the first carries the ID and name.
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (o *OpenAI) Complete(ctx context.Context, req Request) (Reply, error) {
+	resp, err := o.post(ctx, req, false)
+	if err != nil {
+		return Reply{}, err
+	}
+	defer resp.Body.Close()
+	return readCompletion(resp)
+}
+
+// Stream asks for server-sent events. Servers that ignore the stream flag
+// answer with a plain completion, which is emitted whole.
+func (o *OpenAI) Stream(ctx context.Context, req Request, emit func(string)) (Reply, error) {
+	resp, err := o.post(ctx, req, true)
+	if err != nil {
+		return Reply{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		reply, err := readCompletion(resp)
+		if err == nil && reply.Text != "" {
+			emit(reply.Text)
+		}
+		return reply, err
+	}
+
+	var text strings.Builder
+	var calls []wireToolCall
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		data = strings.TrimSpace(data)
+		if !ok || data == "" {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return Reply{}, fmt.Errorf("invalid completion stream: %w", err)
+		}
+		if chunk.Error != nil {
+			return Reply{}, errors.New(chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			// Leading whitespace is trimmed from the final text, so don't
+			// stream it either. Chunks of nothing but whitespace are dropped
+			// until the text starts.
+			content := delta.Content
+			if text.Len() == 0 {
+				content = strings.TrimLeftFunc(content, unicode.IsSpace)
+			}
+			text.WriteString(content)
+			if content != "" {
+				emit(content)
+			}
+		}
+		for _, tc := range delta.ToolCalls {
+			for len(calls) <= tc.Index {
+				calls = append(calls, wireToolCall{})
+			}
+			c := &calls[tc.Index]
+			if tc.ID != "" {
+				c.ID = tc.ID
+			}
+			c.Function.Name += tc.Function.Name
+			c.Function.Arguments += tc.Function.Arguments
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Reply{}, err
+	}
+	return toReply(wireMessage{Content: text.String(), ToolCalls: calls}), nil
+}
+
+func (o *OpenAI) post(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	messages := []wireMessage{{Role: "system", Content: req.System}}
+	for _, m := range req.History {
+		messages = append(messages, toWire(m))
+	}
+	messages = append(messages, wireMessage{Role: RoleUser, Content: req.Prompt})
+	for _, m := range req.Steps {
+		messages = append(messages, toWire(m))
+	}
+
+	var tools []wireTool
+	for _, spec := range req.Tools {
+		var t wireTool
+		t.Type = "function"
+		t.Function.Name, t.Function.Description, t.Function.Parameters = spec.Name, spec.Description, spec.Parameters
+		tools = append(tools, t)
+	}
+
+	body, err := json.Marshal(chatRequest{Model: o.Model, Messages: messages, Tools: tools, Stream: stream})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(o.BaseURL, "/")+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.APIKey)
+	}
+
+	client := o.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return resp, nil
+}
+
+// readCompletion reads a non-streamed completion, or the error a server
+// answered with.
+func readCompletion(resp *http.Response) (Reply, error) {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Reply{}, err
+	}
+	var result chatResponse
+	if err := json.Unmarshal(data, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return Reply{}, fmt.Errorf("invalid completion response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != nil && result.Error.Message != "" {
+			return Reply{}, fmt.Errorf("%s: %s", resp.Status, result.Error.Message)
+		}
+		return Reply{}, errors.New(resp.Status)
+	}
+	if len(result.Choices) == 0 {
+		return Reply{}, errors.New("completion response has no choices")
+	}
+
+	return toReply(result.Choices[0].Message), nil
+}
+
+func toReply(msg wireMessage) Reply {
+	reply := Reply{Text: strings.TrimSpace(msg.Content)}
+	for _, c := range msg.ToolCalls {
+		args := json.RawMessage(c.Function.Arguments)
+		if len(args) == 0 {
+			args = json.RawMessage("{}")
+		}
+		reply.Calls = append(reply.Calls, ToolCall{ID: c.ID, Name: c.Function.Name, Arguments: args})
+	}
+	return reply
+}
@@ -0,0 +1,283 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nathfavour/shadowprism/cli/internal/amount"
+)
+
+// Privacy modes accepted by Config.Privacy.
+const (
+	// PrivacyRedact replaces linkable data with placeholders before a
+	// request leaves the machine and restores it in the reply.
+	PrivacyRedact = "redact"
+	// PrivacyStrict redacts, and refuses to send anything that still holds
+	// a secret: privacy notes, keys or credentials.
+	PrivacyStrict = "strict"
+	// PrivacyOff sends everything as is. Only sensible for a local model.
+	PrivacyOff = "off"
+)
+
+// PrivacyModes lists the modes accepted by Config.Privacy.
+var PrivacyModes = []string{PrivacyRedact, PrivacyStrict, PrivacyOff}
+
+// ErrSensitive is returned in strict mode when a request holds a secret.
+var ErrSensitive = errors.New("request contains secrets")
+
+// A pattern finds one kind of sensitive data. Secret kinds spend funds or
+// grant access: strict mode blocks them and replies never get them back.
+type pattern struct {
+	kind   string
+	re     *regexp.Regexp
+	secret bool
+}
+
+// patterns run in order, so longer forms are replaced before the shorter
+// ones they contain. Base58 excludes 0, O, I and l.
+var patterns = []pattern{
+	{kind: "KEY", secret: true, re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	// A keypair file: 64 byte values.
+	{kind: "KEY", secret: true, re: regexp.MustCompile(`\[\s*\d{1,3}(?:\s*,\s*\d{1,3}){63}\s*\]`)},
+	{kind: "CREDENTIAL", secret: true, re: regexp.MustCompile(`\b\d{8,10}:[A-Za-z0-9_-]{35}\b|\bsk-[A-Za-z0-9_-]{20,}|(?i:api[-_]?key=)[A-Za-z0-9_-]+`)},
+	{kind: "NOTE", secret: true, re: regexp.MustCompile(`\b(?:prism-note|ghost-receipt)-[A-Za-z0-9+/=_-]+`)},
+	{kind: "HASH", re: regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{64}\b`)},
+	// Transaction signatures are 64 bytes, 86 to 88 base58 characters.
+	{kind: "TX", re: regexp.MustCompile(`\b[1-9A-HJ-NP-Za-km-z]{86,88}\b`)},
+	{kind: "ADDRESS", re: regexp.MustCompile(`\b[1-9A-HJ-NP-Za-km-z]{32,44}\b`)},
+	{kind: "AMOUNT", re: amountRe},
+}
+
+// amountRe matches a number followed by a known token or lamports.
+var amountRe = amountPattern()
+
+func amountPattern() *regexp.Regexp {
+	units := []string{"lamports?"}
+	for _, t := range amount.Tokens {
+		units = append(units, regexp.QuoteMeta(t.Symbol))
+	}
+	// Longest first, so "mSOL" isn't read as "m" and "SOL".
+	sort.Slice(units, func(i, j int) bool { return len(units[i]) > len(units[j]) })
+	return regexp.MustCompile(`(?i)\b(\d[\d,]*(?:\.\d+)?)\s?(` + strings.Join(units, "|") + `)\b`)
+}
+
+// placeholderRe matches placeholders in replies, with or without the hint
+// the model was shown, since models often shorten them.
+var placeholderRe = regexp.MustCompile(`\[([A-Z]+_\d+)(?::[^\]\n]*)?\]`)
+
+// Redactor swaps sensitive values for placeholders like [ADDRESS_1] and
+// back. The same value always gets the same placeholder, so the model can
+// still tell that two mentions are the same address.
+type Redactor struct {
+	strict bool
+
+	placeholders map[string]string // value -> placeholder
+	values       map[string]string // placeholder name -> value
+	counts       map[string]int
+	secrets      []string // kinds found in strict mode
+}
+
+// NewRedactor returns an empty Redactor. A strict one records secrets so
+// Err can refuse the request.
+func NewRedactor(strict bool) *Redactor {
+	return &Redactor{
+		strict:       strict,
+		placeholders: map[string]string{},
+		values:       map[string]string{},
+		counts:       map[string]int{},
+	}
+}
+
+// Redact replaces every sensitive value in s.
+func (r *Redactor) Redact(s string) string {
+	for _, p := range patterns {
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.secret && r.strict {
+				r.secrets = append(r.secrets, p.kind)
+			}
+			return r.placeholder(p, match)
+		})
+	}
+	return s
+}
+
+func (r *Redactor) placeholder(p pattern, value string) string {
+	if ph, ok := r.placeholders[value]; ok {
+		return ph
+	}
+	r.counts[p.kind]++
+	name := fmt.Sprintf("%s_%d", p.kind, r.counts[p.kind])
+	ph := "[" + name + "]"
+	if p.kind == "AMOUNT" {
+		ph = "[" + name + ": " + bucket(value) + "]"
+	}
+	if !p.secret {
+		r.values[name] = value
+	}
+	r.placeholders[value] = ph
+	return ph
+}
+
+// Err reports the secrets a strict Redactor found.
+func (r *Redactor) Err() error {
+	if len(r.secrets) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	var kinds []string
+	for _, k := range r.secrets {
+		if !seen[k] {
+			seen[k] = true
+			kinds = append(kinds, strings.ToLower(k))
+		}
+	}
+	return fmt.Errorf("%w (%s); strict privacy mode will not send them", ErrSensitive, strings.Join(kinds, ", "))
+}
+
+// Redacted reports whether anything was replaced.
+func (r *Redactor) Redacted() bool {
+	return len(r.placeholders) > 0
+}
+
+// Restore puts the original values back into a reply. Secrets stay
+// placeholders.
+func (r *Redactor) Restore(s string) string {
+	return placeholderRe.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholderRe.FindStringSubmatch(match)[1]
+		if v, ok := r.values[name]; ok {
+			return v
+		}
+		return match
+	})
+}
+
+// bucket describes an amount by its order of magnitude, e.g. "0.1-1 SOL",
+// which is enough to reason about without matching it on chain.
+func bucket(match string) string {
+	m := amountRe.FindStringSubmatch(match)
+	v, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+	unit := m[2]
+	if t, ok := amount.Lookup(unit); ok {
+		unit = t.Symbol
+	} else {
+		v, unit = v/1e9, "SOL"
+	}
+	switch {
+	case err != nil || v <= 0:
+		return "some " + unit
+	case v < 0.01:
+		return "under 0.01 " + unit
+	case v >= 1e6:
+		return "over 1000000 " + unit
+	}
+	lo := math.Pow(10, math.Floor(math.Log10(v)))
+	return fmt.Sprintf("%s-%s %s", strconv.FormatFloat(lo, 'f', -1, 64), strconv.FormatFloat(lo*10, 'f', -1, 64), unit)
+}
+
+// placeholderNote tells the model how to treat what Redact left behind.
+const placeholderNote = " Private values are replaced by placeholders like [ADDRESS_1] or [AMOUNT_1: 0.1-1 SOL]. " +
+	"Use them exactly as written, including as tool arguments; never guess the real values."
+
+// Private redacts every request before it reaches the wrapped backend and
+// restores the reply, including tool call arguments, so tools still see the
+// real values.
+type Private struct {
+	Assistant Assistant
+	Strict    bool
+}
+
+func (p *Private) Name() string { return p.Assistant.Name() }
+
+func (p *Private) Complete(ctx context.Context, req Request) (Reply, error) {
+	return p.run(ctx, req, nil)
+}
+
+func (p *Private) Stream(ctx context.Context, req Request, emit func(string)) (Reply, error) {
+	return p.run(ctx, req, emit)
+}
+
+func (p *Private) run(ctx context.Context, req Request, emit func(string)) (Reply, error) {
+	r := NewRedactor(p.Strict)
+
+	// The persona is ours and holds no user data, only format examples
+	// that must reach the model intact.
+	out := Request{System: req.System, Tools: req.Tools}
+	for _, m := range req.History {
+		out.History = append(out.History, r.redactMessage(m))
+	}
+	out.Prompt = r.Redact(req.Prompt)
+	for _, m := range req.Steps {
+		out.Steps = append(out.Steps, r.redactMessage(m))
+	}
+	if err := r.Err(); err != nil {
+		return Reply{}, err
+	}
+	if r.Redacted() {
+		out.System += placeholderNote
+	}
+
+	if emit != nil {
+		w := &restorer{r: r, emit: emit}
+		defer w.flush()
+		emit = w.write
+	}
+	reply, err := complete(ctx, p.Assistant, out, emit)
+	if err != nil {
+		return Reply{}, err
+	}
+	reply.Text = r.Restore(reply.Text)
+	for i, c := range reply.Calls {
+		reply.Calls[i].Arguments = r.restoreJSON(c.Arguments)
+	}
+	return reply, nil
+}
+
+func (r *Redactor) redactMessage(m Message) Message {
+	m.Content = r.Redact(m.Content)
+	if len(m.ToolCalls) > 0 {
+		calls := make([]ToolCall, len(m.ToolCalls))
+		for i, c := range m.ToolCalls {
+			c.Arguments = json.RawMessage(r.Redact(string(c.Arguments)))
+			calls[i] = c
+		}
+		m.ToolCalls = calls
+	}
+	return m
+}
+
+// restoreJSON restores placeholders in the strings of a JSON document. It
+// goes through the decoded value so restored text is escaped properly.
+func (r *Redactor) restoreJSON(data json.RawMessage) json.RawMessage {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return json.RawMessage(r.Restore(string(data)))
+	}
+	out, err := json.Marshal(r.restoreValue(v))
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+func (r *Redactor) restoreValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return r.Restore(v)
+	case []any:
+		for i := range v {
+			v[i] = r.restoreValue(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = r.restoreValue(v[k])
+		}
+	}
+	return v
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"strings"
+)
+
+// Streamer is an Assistant that can deliver its reply while the model is
+// still writing it.
+type Streamer interface {
+	Assistant
+	// Stream is Complete, calling emit with each piece of reply text as it
+	// arrives. Tool calls only arrive with the returned Reply.
+	Stream(ctx context.Context, req Request, emit func(delta string)) (Reply, error)
+}
+
+// complete asks a for a reply, streaming its text to emit when both sides
+// can. Backends that can't stream emit their whole reply at once.
+func complete(ctx context.Context, a Assistant, req Request, emit func(string)) (Reply, error) {
+	if emit == nil {
+		return a.Complete(ctx, req)
+	}
+	if s, ok := a.(Streamer); ok {
+		return s.Stream(ctx, req, emit)
+	}
+	reply, err := a.Complete(ctx, req)
+	if err == nil && reply.Text != "" {
+		emit(reply.Text)
+	}
+	return reply, err
+}
+
+// Stream is a reply arriving piece by piece. Read Deltas until it is closed,
+// or cancel the context the stream was started with, then call Wait.
+type Stream struct {
+	// Deltas yields the reply text as it is written.
+	Deltas <-chan string
+
+	done chan struct{}
+	text string
+	err  error
+}
+
+// newStream runs fn in the background, feeding what it emits to Deltas.
+func newStream(ctx context.Context, fn func(emit func(string)) (string, error)) *Stream {
+	deltas := make(chan string, 64)
+	s := &Stream{Deltas: deltas, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer close(deltas)
+		s.text, s.err = fn(func(delta string) {
+			select {
+			case deltas <- delta:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return s
+}
+
+// Wait discards any unread deltas and returns the whole reply, as the
+// non-streaming method would have.
+func (s *Stream) Wait() (string, error) {
+	for range s.Deltas {
+	}
+	<-s.done
+	return s.text, s.err
+}
+
+// TalkStream is Talk, streamed.
+func (a *PrismAgent) TalkStream(ctx context.Context, prompt string) *Stream {
+	return newStream(ctx, func(emit func(string)) (string, error) {
+		return a.talk(ctx, prompt, emit)
+	})
+}
+
+// ChatStream is Chat, streamed.
+func (a *PrismAgent) ChatStream(ctx context.Context, conv *Conversation, input string) *Stream {
+	return newStream(ctx, func(emit func(string)) (string, error) {
+		return a.chat(ctx, conv, input, emit)
+	})
+}
+
+// ActStream is Act, streamed. Text the model writes before calling a tool
+// is streamed too, so a turn may stream more than its final reply.
+func (a *PrismAgent) ActStream(ctx context.Context, conv *Conversation, input string, tools []Tool, op Operator) *Stream {
+	return newStream(ctx, func(emit func(string)) (string, error) {
+		return a.act(ctx, conv, input, tools, op, emit)
+	})
+}
+
+// restorer re-hydrates streamed text. A placeholder may be split across
+// deltas, so text from an unclosed '[' is held back until it closes or
+// grows too long to be one.
+type restorer struct {
+	r       *Redactor
+	emit    func(string)
+	pending string
+}
+
+// maxPlaceholder is longer than any placeholder with its amount hint.
+const maxPlaceholder = 64
+
+func (w *restorer) write(delta string) {
+	w.pending += delta
+	open := strings.LastIndexByte(w.pending, '[')
+	if open < 0 || strings.IndexByte(w.pending[open:], ']') >= 0 || len(w.pending)-open > maxPlaceholder {
+		w.flush()
+		return
+	}
+	if open > 0 {
+		w.emit(w.r.Restore(w.pending[:open]))
+		w.pending = w.pending[open:]
+	}
+}
+
+func (w *restorer) flush() {
+	if w.pending != "" {
+		w.emit(w.r.Restore(w.pending))
+		w.pending = ""
+	}
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Stub is a deterministic backend for tests and demos: it never leaves the
+// machine and always gives the same answer to the same request.
+type Stub struct {
+	// Reply, when set, is returned for every prompt.
+	Reply string
+}
+
+func (s *Stub) Name() string { return BackendStub }
+
+// Complete answers with Reply, or echoes the prompt. When tools are offered,
+// a prompt of the form "call <tool> <json arguments>" calls that tool, and
+// the answer that follows quotes its result.
+func (s *Stub) Complete(ctx context.Context, req Request) (Reply, error) {
+	if err := ctx.Err(); err != nil {
+		return Reply{}, err
+	}
+	if n := len(req.Steps); n > 0 {
+		return Reply{Text: "Stub result: " + req.Steps[n-1].Content}, nil
+	}
+	if call, ok := stubCall(req); ok {
+		return Reply{Calls: []ToolCall{call}}, nil
+	}
+	if s.Reply != "" {
+		return Reply{Text: s.Reply}, nil
+	}
+	reply := "Stub reply to: " + strings.TrimSpace(req.Prompt)
+	if n := len(req.History); n > 0 {
+		reply += fmt.Sprintf(" (after %d earlier messages)", n)
+	}
+	return Reply{Text: reply}, nil
+}
+
+func stubCall(req Request) (ToolCall, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(req.Prompt), "call ")
+	if !ok || len(req.Tools) == 0 {
+		return ToolCall{}, false
+	}
+	name, args, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	args = strings.TrimSpace(args)
+	if args == "" {
+		args = "{}"
+	}
+	if !json.Valid([]byte(args)) {
+		return ToolCall{}, false
+	}
+	return ToolCall{ID: "call_1", Name: name, Arguments: json.RawMessage(args)}, true
+}
+
+// Stream emits the reply Complete would give a word at a time.
+func (s *Stub) Stream(ctx context.Context, req Request, emit func(string)) (Reply, error) {
+	reply, err := s.Complete(ctx, req)
+	if err != nil {
+		return Reply{}, err
+	}
+	for _, word := range strings.SplitAfter(reply.Text, " ") {
+		if err := ctx.Err(); err != nil {
+			return Reply{}, err
+		}
+		emit(word)
+	}
+	return reply, nil
+}
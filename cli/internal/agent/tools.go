@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// maxToolRounds bounds how many times one message may go back to the model
+// with tool results before it has to answer.
+const maxToolRounds = 5
+
+// toolPersona extends persona for turns where the model can operate
+// ShadowPrism. It is told up front that value-moving calls are proposals.
+const toolPersona = persona + " You can operate ShadowPrism with the tools provided. " +
+	"shield, swap and pay move funds: calling them only proposes the exact request, " +
+	"which the user approves or rejects before anything is sent. Never retry an action the user rejected. " +
+	"Amounts are strings like \"0.5 SOL\" or \"50%\" of the wallet balance. " +
+	"Destinations and merchants are Solana addresses or @aliases from list_contacts."
+
+// ToolSpec describes a tool to the model. Parameters is a JSON Schema
+// object for the arguments.
+type ToolSpec struct {
+	Name        string
+	Description string
+	Parameters  map[string]any
+}
+
+// ToolCall is the model asking to run a tool.
+type ToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// Tool is an operation the model may call.
+type Tool struct {
+	ToolSpec
+	// Prepare validates the arguments and resolves them into the exact
+	// action to take. It must not change anything.
+	Prepare func(ctx context.Context, args json.RawMessage) (*Action, error)
+}
+
+// Action is a prepared tool call, ready to run.
+type Action struct {
+	Tool string
+	// Summary says in one line what running the action does.
+	Summary string
+	// Request is exactly what will be sent to the core: the dry-run
+	// preview shown before a value-moving action is approved.
+	Request any
+	// MovesValue actions only run once the Operator confirms them.
+	MovesValue bool
+	// Private is set by a run that produced something the user needs but
+	// the model must not see, such as a payment receipt.
+	Private string
+
+	run func(ctx context.Context) (any, error)
+}
+
+// Operator is the human in the loop of Act.
+type Operator interface {
+	// Confirm shows a value-moving action with its preview and reports
+	// whether the user approved it.
+	Confirm(ctx context.Context, action *Action) bool
+	// Done reports the outcome of an action that ran.
+	Done(action *Action, result any, err error)
+}
+
+// Act answers input like Chat, but lets the model call tools to look things
+// up or propose actions. Value-moving actions run only if op confirms them;
+// read-only ones run straight away. Only the text exchange is remembered in
+// conv.
+func (a *PrismAgent) Act(ctx context.Context, conv *Conversation, input string, tools []Tool, op Operator) (string, error) {
+	return a.act(ctx, conv, input, tools, op, nil)
+}
+
+func (a *PrismAgent) act(ctx context.Context, conv *Conversation, input string, tools []Tool, op Operator, emit func(string)) (string, error) {
+	specs := make([]ToolSpec, len(tools))
+	byName := make(map[string]Tool, len(tools))
+	for i, t := range tools {
+		specs[i] = t.ToolSpec
+		byName[t.Name] = t
+	}
+
+	req := Request{System: toolPersona, History: conv.History(), Prompt: input, Tools: specs}
+	for round := 0; round < maxToolRounds; round++ {
+		reply, err := complete(ctx, a.Assistant, req, emit)
+		if errors.Is(err, ErrUnavailable) {
+			return emitOffline(emit), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("agent error: %w", err)
+		}
+		if len(reply.Calls) == 0 {
+			conv.Append(input, reply.Text)
+			return reply.Text, nil
+		}
+
+		req.Steps = append(req.Steps, Message{Role: RoleAssistant, Content: reply.Text, ToolCalls: reply.Calls})
+		for _, call := range reply.Calls {
+			result := runTool(ctx, byName, call, op)
+			req.Steps = append(req.Steps, Message{Role: RoleTool, ToolCallID: call.ID, Content: result})
+		}
+	}
+	return "", fmt.Errorf("agent error: no answer after %d rounds of tool calls", maxToolRounds)
+}
+
+// runTool runs call and describes the outcome for the model. Failures are
+// reported to the model rather than ending the turn, so it can explain them
+// or try something else.
+func runTool(ctx context.Context, tools map[string]Tool, call ToolCall, op Operator) string {
+	tool, ok := tools[call.Name]
+	if !ok {
+		return fmt.Sprintf("error: unknown tool %q", call.Name)
+	}
+	action, err := tool.Prepare(ctx, call.Arguments)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	if action.MovesValue && (op == nil || !op.Confirm(ctx, action)) {
+		return "declined: the user rejected this action; nothing was sent."
+	}
+
+	result, err := action.run(ctx)
+	if op != nil {
+		op.Done(action, result, err)
+	}
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return string(data)
+}
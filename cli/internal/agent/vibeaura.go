@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// VibeAura runs the vibeaura CLI once per request.
+type VibeAura struct {
+	// Path is the binary; empty searches $PATH, then ~/.local/bin.
+	Path string
+}
+
+func (v *VibeAura) Name() string { return BackendVibeAura }
+
+func (v *VibeAura) Complete(ctx context.Context, req Request) (Reply, error) {
+	return v.Stream(ctx, req, func(string) {})
+}
+
+// Stream emits the CLI's output as it is printed. A reply that may be a
+// tool call is held back until it is clearly prose.
+func (v *VibeAura) Stream(ctx context.Context, req Request, emit func(string)) (Reply, error) {
+	path := v.find()
+	if path == "" {
+		return Reply{}, fmt.Errorf("%w: vibeaura not found", ErrUnavailable)
+	}
+
+	cmd := exec.CommandContext(ctx, path, "direct", "--non-interactive")
+	cmd.Stdin = strings.NewReader(flatten(req))
+	pr, pw := io.Pipe()
+	cmd.Stdout, cmd.Stderr = pw, pw
+	if err := cmd.Start(); err != nil {
+		return Reply{}, fmt.Errorf("vibeaura: %w", err)
+	}
+	waited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		pw.Close()
+		waited <- err
+	}()
+
+	var output strings.Builder
+	streaming := false
+	buf := make([]byte, 4096)
+	for {
+		n, readErr := pr.Read(buf)
+		if n > 0 {
+			chunk := string(buf[:n])
+			if !streaming {
+				// Emit nothing until the first visible character shows
+				// whether this is prose or a tool call.
+				head := strings.TrimLeft(output.String()+chunk, " \t\r\n")
+				if head != "" && (len(req.Tools) == 0 || (head[0] != '{' && head[0] != '`')) {
+					streaming = true
+					chunk = head
+				}
+			}
+			output.Write(buf[:n])
+			if streaming && chunk != "" {
+				emit(chunk)
+			}
+		}
+		if readErr != nil {
+			break
+		}
+	}
+	if err := <-waited; err != nil {
+		if ctx.Err() != nil {
+			return Reply{}, ctx.Err()
+		}
+		return Reply{}, fmt.Errorf("vibeaura: %w", err)
+	}
+
+	text := strings.TrimSpace(output.String())
+	if len(req.Tools) > 0 && !streaming {
+		if call, ok := parseCall(text, len(req.Steps)); ok {
+			return Reply{Calls: []ToolCall{call}}, nil
+		}
+		// It looked like a call but wasn't: send the held-back text.
+		if text != "" {
+			emit(text)
+		}
+	}
+	return Reply{Text: text}, nil
+}
+
+// flatten renders req as the single prompt vibeaura takes: the persona,
+// the tools, the conversation so far, the new message and any tool calls
+// made while answering it.
+func flatten(req Request) string {
+	var b strings.Builder
+	b.WriteString(req.System)
+	if len(req.Tools) > 0 {
+		b.WriteString("\n\nTo use a tool, reply with only a JSON object like " +
+			`{"tool": "<name>", "arguments": {...}}` + " and nothing else. Tools:\n")
+		for _, t := range req.Tools {
+			params, _ := json.Marshal(t.Parameters)
+			fmt.Fprintf(&b, "- %s: %s Arguments schema: %s\n", t.Name, t.Description, params)
+		}
+	}
+	if len(req.History) > 0 {
+		b.WriteString("\n\nConversation so far:\n")
+		for _, m := range req.History {
+			speaker := "User"
+			if m.Role == RoleAssistant {
+				speaker = "ShadowPrism"
+			}
+			fmt.Fprintf(&b, "%s: %s\n", speaker, m.Content)
+		}
+	}
+	b.WriteString(" Context: ")
+	b.WriteString(req.Prompt)
+	for _, m := range req.Steps {
+		for _, c := range m.ToolCalls {
+			fmt.Fprintf(&b, "\nYou called %s with %s.", c.Name, c.Arguments)
+		}
+		if m.Role == RoleTool {
+			fmt.Fprintf(&b, "\nResult: %s", m.Content)
+		}
+	}
+	return b.String()
+}
+
+// parseCall reads a reply that is only a tool call. The CLI has no call
+// IDs, so they are numbered by round.
+func parseCall(text string, round int) (ToolCall, bool) {
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "```json"), "```")
+	var call struct {
+		Tool      string          `json:"tool"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &call); err != nil || call.Tool == "" {
+		return ToolCall{}, false
+	}
+	if len(call.Arguments) == 0 {
+		call.Arguments = json.RawMessage("{}")
+	}
+	return ToolCall{ID: fmt.Sprintf("call_%d", round+1), Name: call.Tool, Arguments: call.Arguments}, true
+}
+
+// find resolves the binary, or returns "" when it isn't installed.
+func (v *VibeAura) find() string {
+	if v.Path != "" {
+		if _, err := os.Stat(v.Path); err != nil {
+			return ""
+		}
+		return v.Path
+	}
+	if path, err := exec.LookPath("vibeaura"); err == nil {
+		return path
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		path := filepath.Join(home, ".local", "bin", "vibeaura")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,270 @@
+// Package amount parses and formats token amounts with exact decimal
+// arithmetic, so "0.1 SOL" is always 100000000 lamports and never a float
+// rounding artefact.
+package amount
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Token is a fungible token and the number of decimals in its base unit.
+type Token struct {
+	Symbol   string
+	Decimals int
+}
+
+// Tokens lists the tokens whose decimals are known, keyed by upper-case symbol.
+var Tokens = map[string]Token{
+	"SOL":     {Symbol: "SOL", Decimals: 9},
+	"MSOL":    {Symbol: "mSOL", Decimals: 9},
+	"JITOSOL": {Symbol: "JitoSOL", Decimals: 9},
+	"USDC":    {Symbol: "USDC", Decimals: 6},
+	"USDT":    {Symbol: "USDT", Decimals: 6},
+	"JUP":     {Symbol: "JUP", Decimals: 6},
+	"WIF":     {Symbol: "WIF", Decimals: 6},
+	"BONK":    {Symbol: "BONK", Decimals: 5},
+}
+
+// baseUnits are unit names that mean the base unit of a token rather than
+// the token itself.
+var baseUnits = map[string]string{
+	"LAMPORT":  "SOL",
+	"LAMPORTS": "SOL",
+}
+
+// ErrNeedsBalance is returned when a percentage is resolved without a balance.
+var ErrNeedsBalance = errors.New("percentage amounts need the wallet balance")
+
+// Lookup finds a token by symbol, ignoring case.
+func Lookup(symbol string) (Token, bool) {
+	t, ok := Tokens[strings.ToUpper(symbol)]
+	return t, ok
+}
+
+// Symbols lists the known token symbols, sorted.
+func Symbols() []string {
+	out := make([]string, 0, len(Tokens))
+	for _, t := range Tokens {
+		out = append(out, t.Symbol)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Amount is a parsed amount: either a number of base units of Token, or a
+// fraction of the wallet's balance of Token.
+type Amount struct {
+	Token string
+	Units uint64
+	// Percent is set for inputs like "10%"; Units is then zero until the
+	// amount is resolved against a balance.
+	Percent *big.Rat
+}
+
+// IsPercent reports whether a is a share of the balance.
+func (a Amount) IsPercent() bool {
+	return a.Percent != nil
+}
+
+// Resolve returns the amount in base units. Percentages are taken of balance,
+// rounding down; a share that rounds to nothing is an error.
+func (a Amount) Resolve(balance *uint64) (uint64, error) {
+	if a.Percent == nil {
+		return a.Units, nil
+	}
+	if balance == nil {
+		return 0, ErrNeedsBalance
+	}
+	share := new(big.Rat).Mul(a.Percent, new(big.Rat).SetUint64(*balance))
+	units := new(big.Int).Quo(share.Num(), share.Denom())
+	if units.Sign() == 0 {
+		return 0, fmt.Errorf("%s of a %s balance is zero", a, Format(*balance, a.Token))
+	}
+	return units.Uint64(), nil
+}
+
+func (a Amount) String() string {
+	if a.Percent != nil {
+		pct := new(big.Rat).Mul(a.Percent, big.NewRat(100, 1))
+		return trimZeros(pct.FloatString(4)) + "%"
+	}
+	return Format(a.Units, a.Token)
+}
+
+// Options controls how Parse reads an input.
+type Options struct {
+	// Token is assumed when the input has no unit.
+	Token string
+	// Whole reads a bare number as whole tokens ("0.5" is half a SOL).
+	// Otherwise a bare whole number is base units, as the CLI always
+	// accepted, and only a bare decimal like "0.5" is whole tokens.
+	Whole bool
+}
+
+// Parse reads inputs like "0.5 SOL", "0.5sol", "0.5", "500000000",
+// "500000000 lamports", "1.25 USDC" or "10%". Zero, negative and out-of-range amounts
+// are rejected, as are more decimal places than the token has.
+func Parse(input string, opts Options) (Amount, error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return Amount{}, errors.New("amount is empty")
+	}
+	if strings.HasPrefix(s, "-") {
+		return Amount{}, fmt.Errorf("amount %q must be positive", input)
+	}
+
+	if num, ok := strings.CutSuffix(s, "%"); ok {
+		return parsePercent(input, strings.TrimSpace(num), opts.Token)
+	}
+
+	num, unit := splitUnit(s)
+	if num == "" {
+		return Amount{}, fmt.Errorf("invalid amount %q: expected a number like 0.5 SOL", input)
+	}
+	// Base units can't have decimals, so a bare decimal can only mean
+	// whole tokens.
+	symbol, whole := opts.Token, opts.Whole || strings.Contains(num, ".")
+	if unit != "" {
+		if tok, ok := baseUnits[strings.ToUpper(unit)]; ok {
+			symbol, whole = tok, false
+		} else {
+			symbol, whole = unit, true
+		}
+	}
+
+	token, ok := Lookup(symbol)
+	if !ok {
+		if whole || strings.Contains(num, ".") {
+			return Amount{}, fmt.Errorf("unknown token %q; known tokens are %s, or give a whole number of base units", symbol, strings.Join(Symbols(), ", "))
+		}
+		token = Token{Symbol: symbol}
+	}
+	if opts.Token != "" && !strings.EqualFold(token.Symbol, opts.Token) {
+		return Amount{}, fmt.Errorf("amount is in %s but %s is expected here", token.Symbol, opts.Token)
+	}
+
+	decimals := 0
+	if whole {
+		decimals = token.Decimals
+	}
+	units, err := scale(num, decimals)
+	if err != nil {
+		if errors.Is(err, errTooPrecise) {
+			if whole {
+				return Amount{}, fmt.Errorf("amount %q has more than %d decimal places for %s", input, token.Decimals, token.Symbol)
+			}
+			return Amount{}, fmt.Errorf("amount %q is in base units, which can't have decimals", input)
+		}
+		return Amount{}, fmt.Errorf("invalid amount %q: %w", input, err)
+	}
+	return Amount{Token: token.Symbol, Units: units}, nil
+}
+
+func parsePercent(input, num, token string) (Amount, error) {
+	bp, err := scale(num, 4)
+	if err != nil {
+		return Amount{}, fmt.Errorf("invalid percentage %q: %w", input, err)
+	}
+	if bp > 100*10000 {
+		return Amount{}, fmt.Errorf("percentage %q is more than 100%%", input)
+	}
+	if t, ok := Lookup(token); ok {
+		token = t.Symbol
+	}
+	return Amount{Token: token, Percent: new(big.Rat).SetFrac64(int64(bp), 100*10000)}, nil
+}
+
+// splitUnit separates "1.5 SOL" or "1.5sol" into its number and unit.
+func splitUnit(s string) (num, unit string) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '_' && r != ','
+	})
+	if i < 0 {
+		return s, ""
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i:])
+}
+
+var errTooPrecise = errors.New("too many decimal places")
+
+// thousandsRe is a whole number grouped with commas, like 1,000,000.
+var thousandsRe = regexp.MustCompile(`^\d{1,3}(?:,\d{3})+$`)
+
+// scale turns a decimal string into an integer count of 10^-decimals units.
+// Underscores may group digits. Commas may only separate thousands left of
+// the decimal point, so "1,5" is never read as 15 when 1.5 was meant.
+func scale(num string, decimals int) (uint64, error) {
+	intPart, frac, _ := strings.Cut(num, ".")
+	if strings.Contains(frac, ",") || (strings.Contains(intPart, ",") && !thousandsRe.MatchString(intPart)) {
+		return 0, errors.New("',' may only separate thousands, as in 1,000; write decimals with '.'")
+	}
+	intPart = strings.NewReplacer("_", "", ",", "").Replace(intPart)
+	frac = strings.ReplaceAll(frac, "_", "")
+	if intPart == "" && frac == "" {
+		return 0, errors.New("no digits")
+	}
+	for _, part := range []string{intPart, frac} {
+		if strings.Trim(part, "0123456789") != "" {
+			return 0, errors.New("not a number")
+		}
+	}
+
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > decimals {
+		return 0, errTooPrecise
+	}
+	digits := strings.TrimLeft(intPart+frac+strings.Repeat("0", decimals-len(frac)), "0")
+	if digits == "" {
+		return 0, errors.New("must be greater than zero")
+	}
+	n, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return 0, errors.New("not a number")
+	}
+	if !n.IsUint64() {
+		return 0, fmt.Errorf("larger than %d base units", uint64(math.MaxUint64))
+	}
+	return n.Uint64(), nil
+}
+
+// Format renders base units of token as a decimal with trailing zeros
+// trimmed, e.g. "0.5 SOL". Tokens with unknown decimals are shown in base
+// units.
+func Format(units uint64, token string) string {
+	t, ok := Lookup(token)
+	if !ok {
+		if token == "" {
+			return fmt.Sprintf("%d", units)
+		}
+		return fmt.Sprintf("%d %s base units", units, token)
+	}
+	return FormatNumber(units, t.Decimals) + " " + t.Symbol
+}
+
+// FormatNumber renders units with the given number of decimals, without a
+// symbol.
+func FormatNumber(units uint64, decimals int) string {
+	s := fmt.Sprintf("%0*d", decimals+1, units)
+	if decimals == 0 {
+		return s
+	}
+	return trimZeros(s[:len(s)-decimals] + "." + s[len(s)-decimals:])
+}
+
+// SOL formats lamports as SOL.
+func SOL(lamports uint64) string {
+	return Format(lamports, "SOL")
+}
+
+func trimZeros(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
@@ -0,0 +1,160 @@
+package amount
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	sol := Options{Token: "SOL"}
+	whole := Options{Token: "SOL", Whole: true}
+
+	tests := []struct {
+		input string
+		opts  Options
+		token string
+		units uint64
+	}{
+		{"0.5 SOL", sol, "SOL", 500_000_000},
+		{"0.5sol", sol, "SOL", 500_000_000},
+		{"1.25 USDC", Options{}, "USDC", 1_250_000},
+		{"500000000", sol, "SOL", 500_000_000},
+		{"500000000 lamports", sol, "SOL", 500_000_000},
+		{"1 lamport", sol, "SOL", 1},
+		{"0.2", sol, "SOL", 200_000_000},
+		{"1", sol, "SOL", 1},
+		{"1", whole, "SOL", 1_000_000_000},
+		{"1_000", sol, "SOL", 1000},
+		{"1,000", sol, "SOL", 1000},
+		{"1,000,000 lamports", sol, "SOL", 1_000_000},
+		{"1,000.5 SOL", sol, "SOL", 1_000_500_000_000},
+		{"  2 SOL  ", sol, "SOL", 2_000_000_000},
+		{".5 SOL", sol, "SOL", 500_000_000},
+		{"25 usdc", Options{Token: "USDC"}, "USDC", 25_000_000},
+		{"100", Options{Token: "XYZ"}, "XYZ", 100},
+	}
+	for _, tt := range tests {
+		a, err := Parse(tt.input, tt.opts)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.input, err)
+			continue
+		}
+		if a.IsPercent() || a.Token != tt.token || a.Units != tt.units {
+			t.Errorf("Parse(%q) = %s %d, want %s %d", tt.input, a.Token, a.Units, tt.token, tt.units)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	sol := Options{Token: "SOL"}
+
+	tests := []struct {
+		input string
+		opts  Options
+	}{
+		{"", sol},
+		{"-1 SOL", sol},
+		{"0", sol},
+		{"0.0 SOL", sol},
+		{"abc", sol},
+		{"1.2.3", sol},
+		{"SOL", sol},
+		// A decimal comma must never be read as a thousands separator.
+		{"1,5 SOL", sol},
+		{"1,5", sol},
+		{"0,5", sol},
+		{"1,00 SOL", sol},
+		{"1,0000", sol},
+		{",100", sol},
+		{"100,", sol},
+		{"1.5,0 SOL", sol},
+		{"1,5%", sol},
+		{"0.0000000001 SOL", sol},
+		{"1.5 lamports", sol},
+		{"1.1234567 USDC", Options{}},
+		{"1 USDC", sol},
+		{"1.5 XYZ", Options{}},
+		{"1.5", Options{Token: "XYZ"}},
+		{"101%", sol},
+		{"18446744073709551616", sol},
+	}
+	for _, tt := range tests {
+		if a, err := Parse(tt.input, tt.opts); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", tt.input, a)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	balance := uint64(2_000_000_000)
+	tiny := uint64(5)
+
+	tests := []struct {
+		input   string
+		balance *uint64
+		units   uint64
+		err     bool
+	}{
+		{"0.5 SOL", nil, 500_000_000, false},
+		{"10%", &balance, 200_000_000, false},
+		{"100%", &balance, 2_000_000_000, false},
+		{"0.25%", &balance, 5_000_000, false},
+		{"10%", &tiny, 0, true},
+		{"10%", nil, 0, true},
+	}
+	for _, tt := range tests {
+		a, err := Parse(tt.input, Options{Token: "SOL"})
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.input, err)
+		}
+		units, err := a.Resolve(tt.balance)
+		if (err != nil) != tt.err || units != tt.units {
+			t.Errorf("Resolve(%q) = %d, %v; want %d, error %v", tt.input, units, err, tt.units, tt.err)
+		}
+	}
+
+	a, _ := Parse("10%", Options{Token: "SOL"})
+	if _, err := a.Resolve(nil); !errors.Is(err, ErrNeedsBalance) {
+		t.Errorf("Resolve(nil) error = %v, want ErrNeedsBalance", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		units uint64
+		token string
+		want  string
+	}{
+		{500_000_000, "SOL", "0.5 SOL"},
+		{1_000_000_000, "sol", "1 SOL"},
+		{1, "SOL", "0.000000001 SOL"},
+		{1_250_000, "USDC", "1.25 USDC"},
+		{42, "", "42"},
+		{42, "XYZ", "42 XYZ base units"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.units, tt.token); got != tt.want {
+			t.Errorf("Format(%d, %q) = %q, want %q", tt.units, tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"12.5%", "12.5%"},
+		{"0.01%", "0.01%"},
+		{"1.5 SOL", "1.5 SOL"},
+	}
+	for _, tt := range tests {
+		a, err := Parse(tt.input, Options{Token: "SOL"})
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.input, err)
+		}
+		if got := a.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -1,35 +1,139 @@
 package embed
 
 import (
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/nathfavour/shadowprism/cli/internal/fslock"
 )
 
 //go:embed core_bin
 var coreBinary []byte
 
-// ExtractCore extracts the embedded Rust binary to a temporary location
-// and returns the path to the executable.
+// ErrCoreTampered is returned when the core on disk and the digest recorded
+// for it disagree, and the digest isn't the embedded binary's either.
+var ErrCoreTampered = errors.New("core binary on disk has been modified")
+
+// CoreDigest is the hex SHA-256 of the embedded core binary.
+func CoreDigest() string {
+	sum := sha256.Sum256(coreBinary)
+	return hex.EncodeToString(sum[:])
+}
+
+// ExtractCore extracts the embedded Rust binary to ~/.shadowprism/bin and
+// returns the path to the executable. The file is only rewritten when its
+// hash differs from the embedded binary, and always atomically, so commands
+// starting at the same time never see a half-written core.
 func ExtractCore() (string, error) {
-	tempDir, err := os.UserHomeDir()
+	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
 
-	appDir := filepath.Join(tempDir, ".shadowprism", "bin")
+	appDir := filepath.Join(home, ".shadowprism", "bin")
 	if err := os.MkdirAll(appDir, 0700); err != nil {
 		return "", err
 	}
 
 	binPath := filepath.Join(appDir, "shadowprism-core")
+	// digestPath records the hash of the binary we last extracted, which
+	// tells an older core we wrote apart from one modified behind our back.
+	digestPath := binPath + ".sha256"
 
-	// We overwrite the binary every time to ensure it's up to date with the CLI version
-	err = os.WriteFile(binPath, coreBinary, 0700)
+	lock, err := fslock.Acquire(filepath.Join(appDir, "extract.lock"))
 	if err != nil {
-		return "", fmt.Errorf("failed to write embedded binary: %w", err)
+		return "", err
+	}
+	defer lock.Release()
+
+	want := CoreDigest()
+	data, err := os.ReadFile(digestPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	recorded := strings.TrimSpace(string(data))
+	have, err := fileDigest(binPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// First run.
+	case err != nil:
+		return "", err
+	case have == want:
+		// Already extracted, possibly by install.sh.
+	case recorded == "":
+		// Installed before digests were recorded; upgrade it.
+	case recorded != have && recorded != want:
+		return "", fmt.Errorf("%w: %s (sha256 %s); delete it to re-extract the embedded core", ErrCoreTampered, binPath, have)
+	}
+
+	if have != want {
+		if err := writeAtomic(binPath, coreBinary, 0700); err != nil {
+			return "", fmt.Errorf("failed to write embedded binary: %w", err)
+		}
+	}
+	if recorded != want {
+		if err := writeAtomic(digestPath, []byte(want+"\n"), 0600); err != nil {
+			return "", fmt.Errorf("failed to record core digest: %w", err)
+		}
 	}
 
 	return binPath, nil
 }
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeAtomic replaces path via a synced temp file and a rename. Replacing a
+// running executable this way is safe; writing over it fails with ETXTBSY.
+func writeAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself; not every platform can sync a directory.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
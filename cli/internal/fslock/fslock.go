@@ -0,0 +1,32 @@
+// Package fslock provides advisory locks on files, shared between processes.
+package fslock
+
+import "os"
+
+// Lock is an exclusive advisory lock held on an open file.
+type Lock struct {
+	f *os.File
+}
+
+// Acquire blocks until it holds an exclusive lock on path, creating the file
+// if needed. The lock is dropped automatically if the process dies.
+func Acquire(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lock(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release drops the lock and closes the file.
+func (l *Lock) Release() error {
+	if err := unlock(l.f); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package fslock
+
+import "os"
+
+// Other platforms only run a single foreground core, so locking is a no-op.
+func lock(f *os.File) error { return nil }
+
+func unlock(f *os.File) error { return nil }
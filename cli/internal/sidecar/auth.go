@@ -0,0 +1,58 @@
+package sidecar
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// authTokenSecret is the encrypted secret holding the bearer token of the
+// most recently launched core. It is always sealed with the machine key:
+// the token dies with the core and is guarded by file permissions, and
+// sealing it with a passphrase would prompt on every command.
+const authTokenSecret = "core_auth_token"
+
+// ErrNoAuthToken means no core has been launched from this home directory yet.
+var ErrNoAuthToken = errors.New("no core auth token found; the core has not been started yet")
+
+// GenerateAuthToken returns a fresh 256-bit bearer token.
+func GenerateAuthToken() (string, error) {
+	var buf [32]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", fmt.Errorf("failed to generate auth token: %w", err)
+	}
+	return hex.EncodeToString(buf[:]), nil
+}
+
+// saveAuthToken persists the token of a core being launched.
+func (cm *ConfigManager) saveAuthToken(token string) error {
+	key, _ := machineKey{}.Key()
+	return cm.saveSealed(authTokenSecret, token, &key)
+}
+
+// LoadAuthToken returns the token of the current core launch.
+func (cm *ConfigManager) LoadAuthToken() (string, error) {
+	data, err := os.ReadFile(filepath.Join(cm.HomeDir, authTokenSecret+".enc"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNoAuthToken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	key, _ := machineKey{}.Key()
+	return openSecret(&key, data)
+}
+
+// NewClient builds a CoreClient for the core owned by cm, authenticating with
+// the token persisted by the Manager that launched it.
+func NewClient(cm *ConfigManager) (*CoreClient, error) {
+	token, err := cm.LoadAuthToken()
+	if err != nil {
+		return nil, err
+	}
+	return NewCoreClient(cm.GetSocketPath(), token), nil
+}
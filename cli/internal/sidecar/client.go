@@ -2,17 +2,40 @@ package sidecar
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/nathfavour/shadowprism/cli/api"
+	"github.com/nathfavour/shadowprism/cli/internal/amount"
 )
 
+// Timeouts bounds each class of core call when the caller's context carries
+// no deadline of its own.
+type Timeouts struct {
+	Status      time.Duration
+	Query       time.Duration
+	Transaction time.Duration
+}
+
+// DefaultTimeouts leaves room for on-chain confirmation on value-moving calls
+// while keeping read-only calls snappy.
+var DefaultTimeouts = Timeouts{
+	Status:      5 * time.Second,
+	Query:       15 * time.Second,
+	Transaction: 2 * time.Minute,
+}
+
 type CoreClient struct {
-	Http   *resty.Client
-	Socket string
+	Http     *resty.Client
+	Socket   string
+	Timeouts Timeouts
 }
 
 func NewCoreClient(socketPath string, token string) *CoreClient {
@@ -20,474 +43,256 @@ func NewCoreClient(socketPath string, token string) *CoreClient {
 
 	// Configure UDS Dialer
 	transport := &http.Transport{
-		DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-			return net.Dial("unix", socketPath)
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
 		},
 	}
 
 	client.SetTransport(transport).
 		SetBaseURL("http://localhost"). // Hostname is ignored by UDS dialer
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
-		SetTimeout(5 * time.Second)
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token))
 
 	return &CoreClient{
-		Http:   client,
-		Socket: socketPath,
+		Http:     client,
+		Socket:   socketPath,
+		Timeouts: DefaultTimeouts,
 	}
 }
 
-func (c *CoreClient) GetStatus() (map[string]interface{}, error) {
-
-	var result map[string]interface{}
-
-	resp, err := c.Http.R().
-
-		SetResult(&result).
-
-		Get("/health")
-
-
+// request starts a resty request bound to ctx, applying the fallback timeout
+// only when ctx has no deadline. The returned cancel func must be called.
+func (c *CoreClient) request(ctx context.Context, fallback time.Duration) (*resty.Request, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if _, ok := ctx.Deadline(); !ok && fallback > 0 {
+		ctx, cancel = context.WithTimeout(ctx, fallback)
+	}
+	return c.Http.R().SetContext(ctx), cancel
+}
 
-	if err != nil {
+func (c *CoreClient) GetStatus(ctx context.Context) (*api.HealthStatus, error) {
+	req, cancel := c.request(ctx, c.Timeouts.Status)
+	defer cancel()
 
+	resp, err := req.Get("/health")
+	if err := checkResponse("status", resp, err); err != nil {
 		return nil, err
-
 	}
 
-	if resp.IsError() {
-
-		return nil, fmt.Errorf("core error: %s", resp.Status())
-
+	// The health endpoint replies with a JSON body but a text/plain content
+	// type, so resty won't decode it for us.
+	var result api.HealthStatus
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return nil, fmt.Errorf("invalid health response: %w", err)
 	}
-
-	return result, nil
-
+	return &result, nil
 }
 
+// GetHistory lists transactions newest first, narrowed by q.
+func (c *CoreClient) GetHistory(ctx context.Context, q api.HistoryQuery) ([]api.TransactionRecord, error) {
+	var result []api.TransactionRecord
+	req, cancel := c.request(ctx, c.Timeouts.Query)
+	defer cancel()
 
-
-func (c *CoreClient) GetHistory() ([]map[string]interface{}, error) {
-
-
-
-	var result []map[string]interface{}
-
-
-
-	resp, err := c.Http.R().
-
-
-
+	resp, err := req.
+		SetQueryParamsFromValues(historyParams(q)).
 		SetResult(&result).
-
-
-
 		Get("/v1/history")
-
-
-
-
-
-
-
-	if err != nil {
-
-
-
+	if err := checkResponse("history", resp, err); err != nil {
 		return nil, err
-
-
-
 	}
-
-
-
-	if resp.IsError() {
-
-
-
-		return nil, fmt.Errorf("core error: %s", resp.Status())
-
-
-
-	}
-
-
-
 	return result, nil
-
-
-
 }
 
-
-
-
-
-
-
-func (c *CoreClient) Shield(amount uint64, dest string, strategy string, force bool) (map[string]interface{}, error) {
-
-
-
-
-
-
-
-	var result map[string]interface{}
-
-
-
-
-
-
-
-	payload := map[string]interface{}{
-
-
-
-
-
-
-
-		"amount_lamports":  amount,
-
-
-
-
-
-
-
-		"destination_addr": dest,
-
-
-
-
-
-
-
-		"strategy":         strategy,
-
-
-
-
-
-
-
-		"force":            force,
-
-
-
-
-
-
-
+func historyParams(q api.HistoryQuery) url.Values {
+	v := url.Values{}
+	if q.Status != "" {
+		v.Set("status", string(q.Status))
 	}
+	if q.Provider != "" {
+		v.Set("provider", q.Provider)
+	}
+	if q.Destination != "" {
+		v.Set("destination", q.Destination)
+	}
+	if !q.Since.IsZero() {
+		v.Set("since", q.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if !q.Until.IsZero() {
+		v.Set("until", q.Until.UTC().Format(time.RFC3339Nano))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Offset > 0 {
+		v.Set("offset", strconv.Itoa(q.Offset))
+	}
+	return v
+}
 
+func (c *CoreClient) Shield(ctx context.Context, amount uint64, dest string, strategy string, force bool) (*api.ShieldResponse, error) {
+	var result api.ShieldResponse
+	payload := api.ShieldRequest{
+		AmountLamports:  amount,
+		DestinationAddr: dest,
+		Strategy:        strategy,
+		Force:           force,
+	}
 
+	req, cancel := c.request(ctx, c.Timeouts.Transaction)
+	defer cancel()
 
-
-
-
-
-
-
-
-
-
-
-
-
-	resp, err := c.Http.R().
-
-
-
-
-
-
-
-
-
-
-
+	resp, err := req.
 		SetBody(payload).
-
-
-
 		SetResult(&result).
-
-
-
 		Post("/v1/shield")
-
-
-
-
-
-
-
-	if err != nil {
-
-
-
+	if err := checkResponse("shield", resp, err); err != nil {
 		return nil, err
-
-
-
 	}
-
-
-
-	if resp.IsError() {
-
-
-
-		return nil, fmt.Errorf("shield failed: %s", resp.String())
-
-
-
-	}
-
-
-
-	return result, nil
-
-
-
+	return &result, nil
 }
 
-
-
-
-
-
-
-func (c *CoreClient) Swap(amount uint64, from, to string) (map[string]interface{}, error) {
-
-
-
-	var result map[string]interface{}
-
-
-
-	payload := map[string]interface{}{
-
-
-
-		"amount_lamports": amount,
-
-
-
-		"from_token":      from,
-
-
-
-		"to_token":        to,
-
-
-
+func (c *CoreClient) Swap(ctx context.Context, amount uint64, from, to string) (*api.SwapResponse, error) {
+	var result api.SwapResponse
+	payload := api.SwapRequest{
+		AmountLamports: amount,
+		FromToken:      from,
+		ToToken:        to,
 	}
 
+	req, cancel := c.request(ctx, c.Timeouts.Transaction)
+	defer cancel()
 
-
-
-
-
-
-	resp, err := c.Http.R().
-
-
-
+	resp, err := req.
 		SetBody(payload).
-
-
-
 		SetResult(&result).
-
-
-
 		Post("/v1/swap")
-
-
-
-
-
-
-
-	if err != nil {
-
-
-
+	if err := checkResponse("swap", resp, err); err != nil {
 		return nil, err
-
-
-
-	}
-
-
-
-	if resp.IsError() {
-
-
-
-		return nil, fmt.Errorf("swap failed: %s", resp.String())
-
-
-
 	}
-
-
-
-	return result, nil
-
-
-
+	return &result, nil
 }
 
-
-
-
-
-
-
-func (c *CoreClient) Pay(amount uint64, merchant string) (map[string]interface{}, error) {
-
-
-
-	var result map[string]interface{}
-
-
-
-	payload := map[string]interface{}{
-
-
-
-		"amount_lamports": amount,
-
-
-
-		"merchant_id":     merchant,
-
-
-
+func (c *CoreClient) Pay(ctx context.Context, amount uint64, merchant string) (*api.PayResponse, error) {
+	var result api.PayResponse
+	payload := api.PayRequest{
+		AmountLamports: amount,
+		MerchantID:     merchant,
 	}
 
+	req, cancel := c.request(ctx, c.Timeouts.Transaction)
+	defer cancel()
 
-
-
-
-
-
-	resp, err := c.Http.R().
-
-
-
+	resp, err := req.
 		SetBody(payload).
-
-
-
 		SetResult(&result).
-
-
-
 		Post("/v1/pay")
-
-
-
-
-
-
-
-	if err != nil {
-
-
-
+	if err := checkResponse("pay", resp, err); err != nil {
 		return nil, err
-
-
-
 	}
-
-
-
-	if resp.IsError() {
-
-
-
-		return nil, fmt.Errorf("pay failed: %s", resp.String())
-
-
-
-	}
-
-
-
-	return result, nil
-
-
-
+	return &result, nil
 }
 
+func (c *CoreClient) GetMarket(ctx context.Context) (*api.MarketData, error) {
+	var result api.MarketData
+	req, cancel := c.request(ctx, c.Timeouts.Query)
+	defer cancel()
 
-
-
-
-
-
-func (c *CoreClient) GetMarket() (map[string]interface{}, error) {
-
-
-
-	var result map[string]interface{}
-
-
-
-	resp, err := c.Http.R().
-
-
-
+	resp, err := req.
 		SetResult(&result).
-
-
-
 		Get("/v1/market")
+	if err := checkResponse("market", resp, err); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
 
+func (c *CoreClient) GetBalance(ctx context.Context) (*api.Balance, error) {
+	var result api.Balance
+	req, cancel := c.request(ctx, c.Timeouts.Query)
+	defer cancel()
 
+	resp, err := req.
+		SetResult(&result).
+		Get("/v1/balance")
+	if err := checkResponse("balance", resp, err); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
 
-
-
-
-
+// ResolveAmount returns a in base units, fetching the wallet balance when a
+// is a percentage. Only the SOL balance is known, so a percentage of any
+// other token is an error.
+func (c *CoreClient) ResolveAmount(ctx context.Context, a amount.Amount) (uint64, error) {
+	if !a.IsPercent() {
+		return a.Units, nil
+	}
+	if !strings.EqualFold(a.Token, "SOL") {
+		return 0, fmt.Errorf("%w, and only the SOL balance is known; give an exact %s amount", amount.ErrNeedsBalance, a.Token)
+	}
+	bal, err := c.GetBalance(ctx)
 	if err != nil {
+		return 0, err
+	}
+	return a.Resolve(&bal.Lamports)
+}
 
+func (c *CoreClient) GetTask(ctx context.Context, id string) (*api.TransactionRecord, error) {
+	var result api.TransactionRecord
+	req, cancel := c.request(ctx, c.Timeouts.Status)
+	defer cancel()
 
-
+	resp, err := req.
+		SetPathParam("id", id).
+		SetResult(&result).
+		Get("/v1/tasks/{id}")
+	if err := checkResponse("task", resp, err); err != nil {
 		return nil, err
-
-
-
 	}
+	return &result, nil
+}
 
+// Backoff bounds for WaitForTask. The core's watchdog re-checks pending
+// transactions every 30s, so polling faster than that buys little.
+const (
+	taskPollMin = 1 * time.Second
+	taskPollMax = 15 * time.Second
+)
 
-
-	if resp.IsError() {
-
-
-
-		return nil, fmt.Errorf("market error: %s", resp.Status())
-
-
-
+// WaitForTask polls a task until it reaches a terminal status or ctx is done.
+// Transport errors are retried; any other core error is returned as-is. On
+// ctx expiry the last record seen is returned alongside ctx.Err().
+func (c *CoreClient) WaitForTask(ctx context.Context, id string) (*api.TransactionRecord, error) {
+	var last *api.TransactionRecord
+	delay := taskPollMin
+
+	for {
+		// Each poll gets its own short deadline so a hung request can't
+		// swallow the caller's whole budget.
+		pollCtx, cancel := context.WithTimeout(ctx, c.Timeouts.Status)
+		rec, err := c.GetTask(pollCtx, id)
+		cancel()
+
+		switch {
+		case err == nil:
+			last = rec
+			if rec.Status.Terminal() {
+				return rec, nil
+			}
+		case !IsKind(err, KindTransport):
+			return last, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return last, ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > taskPollMax {
+			delay = taskPollMax
+		}
 	}
-
-
-
-	return result, nil
-
-
-
 }
-
-
-
-
@@ -2,71 +2,103 @@ package sidecar
 
 import (
 	"crypto/rand"
-	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"runtime"
+	"sync"
 
 	"golang.org/x/crypto/nacl/secretbox"
 )
 
 type ConfigManager struct {
+	// HomeDir holds the active profile's socket, secrets and core data.
 	HomeDir string
-	Key     [32]byte
+	Profile string
+
+	keyMu sync.Mutex
+	key   *[32]byte
 }
 
+// NewConfigManager opens the active profile; see ActiveProfile.
 func NewConfigManager() (*ConfigManager, error) {
-	home, err := os.UserHomeDir()
+	name, err := ActiveProfile()
 	if err != nil {
 		return nil, err
 	}
+	return OpenProfile(name)
+}
 
-	appDir := filepath.Join(home, ".shadowprism")
-	if err := os.MkdirAll(appDir, 0700); err != nil {
-		return nil, err
+// secretKey unlocks the configured key source on first use, so commands
+// that never touch secrets don't prompt for a passphrase.
+func (cm *ConfigManager) secretKey() ([32]byte, error) {
+	cm.keyMu.Lock()
+	defer cm.keyMu.Unlock()
+	if cm.key != nil {
+		return *cm.key, nil
 	}
 
-	cm := &ConfigManager{HomeDir: appDir}
-	if err := cm.initKey(); err != nil {
-		return nil, err
+	key, err := cm.unlockKey()
+	if err != nil {
+		return key, err
 	}
+	cm.key = &key
+	return key, nil
+}
 
-	return cm, nil
+func (cm *ConfigManager) SaveSecret(name string, value string) error {
+	if err := ValidateSecretName(name); err != nil {
+		return err
+	}
+	key, err := cm.secretKey()
+	if err != nil {
+		return err
+	}
+	if err := cm.saveSealed(name, value, &key); err != nil {
+		return err
+	}
+	return cm.recordSecretWrite(name)
 }
 
-// initKey generates a machine-specific key so we don't need user prompts.
-func (cm *ConfigManager) initKey() error {
-	// We use a combination of hostname and OS-specific markers to derive a key
-	hostname, _ := os.Hostname()
-	machineID := fmt.Sprintf("%s-%s-%s", hostname, runtime.GOOS, runtime.GOARCH)
-	
-	hash := sha256.Sum256([]byte(machineID))
-	copy(cm.Key[:], hash[:])
-	return nil
+func (cm *ConfigManager) LoadSecret(name string) (string, error) {
+	if err := ValidateSecretName(name); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(cm.secretPath(name))
+	if err != nil {
+		return "", err
+	}
+
+	key, err := cm.secretKey()
+	if err != nil {
+		return "", err
+	}
+	return openSecret(&key, data)
 }
 
-func (cm *ConfigManager) SaveSecret(name string, value string) error {
-	var nonce [24]byte
-	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+func (cm *ConfigManager) saveSealed(name, value string, key *[32]byte) error {
+	sealed, err := sealSecret(key, value)
+	if err != nil {
 		return err
 	}
 
-	encrypted := secretbox.Seal(nonce[:], []byte(value), &nonce, &cm.Key)
-	
 	path := filepath.Join(cm.HomeDir, name+".enc")
-	return os.WriteFile(path, []byte(hex.EncodeToString(encrypted)), 0600)
+	return os.WriteFile(path, sealed, 0600)
 }
 
-func (cm *ConfigManager) LoadSecret(name string) (string, error) {
-	path := filepath.Join(cm.HomeDir, name+".enc")
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
+// sealSecret encrypts value with secretbox and hex encodes nonce+box.
+func sealSecret(key *[32]byte, value string) ([]byte, error) {
+	var nonce [24]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, err
 	}
 
+	encrypted := secretbox.Seal(nonce[:], []byte(value), &nonce, key)
+	return []byte(hex.EncodeToString(encrypted)), nil
+}
+
+func openSecret(key *[32]byte, data []byte) (string, error) {
 	encrypted, err := hex.DecodeString(string(data))
 	if err != nil {
 		return "", err
@@ -78,8 +110,8 @@ func (cm *ConfigManager) LoadSecret(name string) (string, error) {
 
 	var nonce [24]byte
 	copy(nonce[:], encrypted[:24])
-	
-	decrypted, ok := secretbox.Open(nil, encrypted[24:], &nonce, &cm.Key)
+
+	decrypted, ok := secretbox.Open(nil, encrypted[24:], &nonce, key)
 	if !ok {
 		return "", fmt.Errorf("decryption failed")
 	}
@@ -0,0 +1,187 @@
+package sidecar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/internal/address"
+	"github.com/nathfavour/shadowprism/cli/internal/fslock"
+)
+
+// contactsSecret is the encrypted address book. Who a user pays is as
+// sensitive as any API key, so the whole book is sealed with the secret key.
+const contactsSecret = "contacts"
+
+var (
+	ErrContactNotFound = errors.New("no such contact")
+	ErrContactExists   = errors.New("contact already exists")
+	ErrInvalidAlias    = errors.New("invalid alias")
+)
+
+var aliasRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+
+// Contact is an address book entry, referred to as @alias.
+type Contact struct {
+	Alias   string    `json:"-"`
+	Address string    `json:"address"`
+	Note    string    `json:"note,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// AddressBook resolves @alias references to addresses.
+type AddressBook struct {
+	contacts map[string]*Contact
+}
+
+// NormalizeAlias strips a leading '@', lowercases alias and validates it.
+func NormalizeAlias(alias string) (string, error) {
+	name := strings.ToLower(strings.TrimPrefix(alias, "@"))
+	if !aliasRe.MatchString(name) {
+		return "", fmt.Errorf("%w %q: use up to 32 letters, digits, '_', '.' or '-'", ErrInvalidAlias, alias)
+	}
+	return name, nil
+}
+
+// LoadAddressBook decrypts the address book. A profile without one gets an
+// empty book without unlocking the secret key.
+func (cm *ConfigManager) LoadAddressBook() (*AddressBook, error) {
+	book := &AddressBook{contacts: map[string]*Contact{}}
+	data, err := os.ReadFile(cm.secretPath(contactsSecret))
+	if errors.Is(err, os.ErrNotExist) {
+		return book, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := cm.secretKey()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := openSecret(&key, data)
+	if err != nil {
+		return nil, fmt.Errorf("address book: %w", err)
+	}
+	if err := json.Unmarshal([]byte(plain), &book.contacts); err != nil {
+		return nil, fmt.Errorf("address book: %w", err)
+	}
+	for alias, c := range book.contacts {
+		c.Alias = alias
+	}
+	return book, nil
+}
+
+// updateAddressBook applies fn to the address book under a lock and saves it
+// if fn succeeds.
+func (cm *ConfigManager) updateAddressBook(fn func(book *AddressBook) error) error {
+	lock, err := fslock.Acquire(filepath.Join(cm.HomeDir, "contacts.lock"))
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	book, err := cm.LoadAddressBook()
+	if err != nil {
+		return err
+	}
+	if err := fn(book); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(book.contacts)
+	if err != nil {
+		return err
+	}
+	key, err := cm.secretKey()
+	if err != nil {
+		return err
+	}
+	sealed, err := sealSecret(&key, string(data))
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cm.secretPath(contactsSecret), sealed)
+}
+
+// AddContact stores addr under alias. An existing alias is only overwritten
+// when replace is set.
+func (cm *ConfigManager) AddContact(alias, addr, note string, replace bool) (Contact, error) {
+	name, err := NormalizeAlias(alias)
+	if err != nil {
+		return Contact{}, err
+	}
+	if err := address.Validate(addr); err != nil {
+		return Contact{}, err
+	}
+
+	c := Contact{Alias: name, Address: addr, Note: note, AddedAt: time.Now().UTC()}
+	err = cm.updateAddressBook(func(book *AddressBook) error {
+		if _, ok := book.contacts[name]; ok && !replace {
+			return fmt.Errorf("%w: @%s", ErrContactExists, name)
+		}
+		book.contacts[name] = &c
+		return nil
+	})
+	return c, err
+}
+
+// RemoveContact deletes alias from the address book.
+func (cm *ConfigManager) RemoveContact(alias string) error {
+	name, err := NormalizeAlias(alias)
+	if err != nil {
+		return err
+	}
+	return cm.updateAddressBook(func(book *AddressBook) error {
+		if _, ok := book.contacts[name]; !ok {
+			return fmt.Errorf("%w: @%s", ErrContactNotFound, name)
+		}
+		delete(book.contacts, name)
+		return nil
+	})
+}
+
+// ResolveAddress turns "@alias" into the stored address and validates plain
+// addresses. The address book is only decrypted for aliases.
+func (cm *ConfigManager) ResolveAddress(input string) (string, error) {
+	if !strings.HasPrefix(input, "@") {
+		return input, address.Validate(input)
+	}
+	book, err := cm.LoadAddressBook()
+	if err != nil {
+		return "", err
+	}
+	return book.Resolve(input)
+}
+
+// Contacts returns the entries sorted by alias.
+func (b *AddressBook) Contacts() []Contact {
+	out := make([]Contact, 0, len(b.contacts))
+	for _, c := range b.contacts {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Alias < out[j].Alias })
+	return out
+}
+
+// Resolve turns "@alias" into its address and validates plain addresses.
+func (b *AddressBook) Resolve(input string) (string, error) {
+	if !strings.HasPrefix(input, "@") {
+		return input, address.Validate(input)
+	}
+	name, err := NormalizeAlias(input)
+	if err != nil {
+		return "", err
+	}
+	c, ok := b.contacts[name]
+	if !ok {
+		return "", fmt.Errorf("%w: @%s (add it with `shadowprism contacts add %s <address>`)", ErrContactNotFound, name, name)
+	}
+	return c.Address, nil
+}
@@ -0,0 +1,71 @@
+package sidecar
+
+import (
+	"fmt"
+	"os"
+)
+
+// ProviderCredential is a provider secret the core reads from its
+// environment. Storing it under Secret lets the manager hand it to the core
+// without it ever being exported in the user's shell.
+type ProviderCredential struct {
+	Secret string
+	Env    string
+	Help   string
+}
+
+// ProviderCredentials declares every credential forwarded to the core.
+var ProviderCredentials = []ProviderCredential{
+	{Secret: "HELIUS_RPC_URL", Env: "HELIUS_RPC_URL", Help: "Primary RPC endpoint, including its API key"},
+	{Secret: "QUICKNODE_RPC_URL", Env: "QUICKNODE_RPC_URL", Help: "Fallback RPC endpoint, including its API key"},
+	{Secret: "RANGE_API_KEY", Env: "RANGE_API_KEY", Help: "Range Protocol compliance API key"},
+}
+
+// CoreEnvVar is one variable set in the core's environment.
+type CoreEnvVar struct {
+	Name   string
+	Value  string
+	Source string
+	// Sensitive values are redacted whenever they are displayed.
+	Sensitive bool
+}
+
+// CoreEnv resolves the variables a core launched from cm gets on top of our
+// own environment: settings first, then provider credentials from the
+// secret store. A variable already exported in our environment wins over
+// both, and its value is inherited rather than set again.
+func (cm *ConfigManager) CoreEnv() ([]CoreEnvVar, error) {
+	settings, err := cm.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	env := settings.CoreEnv()
+
+	for _, cred := range ProviderCredentials {
+		if _, ok := os.LookupEnv(cred.Env); ok {
+			env = setCoreEnv(env, CoreEnvVar{Name: cred.Env, Value: os.Getenv(cred.Env), Source: "environment", Sensitive: true})
+			continue
+		}
+		if !cm.SecretExists(cred.Secret) {
+			continue
+		}
+
+		value, err := cm.LoadSecret(cred.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret %s: %w", cred.Secret, err)
+		}
+		env = setCoreEnv(env, CoreEnvVar{Name: cred.Env, Value: value, Source: "secret " + cred.Secret, Sensitive: true})
+	}
+	return env, nil
+}
+
+// setCoreEnv replaces the variable named v.Name in env, or appends v.
+func setCoreEnv(env []CoreEnvVar, v CoreEnvVar) []CoreEnvVar {
+	for i := range env {
+		if env[i].Name == v.Name {
+			env[i] = v
+			return env
+		}
+	}
+	return append(env, v)
+}
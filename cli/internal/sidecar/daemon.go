@@ -0,0 +1,232 @@
+package sidecar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/internal/fslock"
+)
+
+// Version is the CLI version, recorded in the pidfile of daemons it starts.
+// Overridden at build time with -ldflags "-X ...sidecar.Version=...".
+var Version = "dev"
+
+// DefaultStopGrace is how long StopDaemon waits after SIGTERM before it
+// resorts to SIGKILL.
+const DefaultStopGrace = 10 * time.Second
+
+var (
+	ErrDaemonRunning    = errors.New("core daemon is already running")
+	ErrDaemonNotRunning = errors.New("core daemon is not running")
+)
+
+// DaemonInfo is persisted in the pidfile of a detached core.
+type DaemonInfo struct {
+	PID       int       `json:"pid"`
+	Profile   string    `json:"profile,omitempty"`
+	Port      int       `json:"port"`
+	Socket    string    `json:"socket"`
+	Version   string    `json:"version"`
+	StartedAt time.Time `json:"started_at"`
+	// Binary and ProcStart identify the process, so a PID reused after the
+	// core exited (or after a reboot) is never mistaken for it.
+	Binary    string `json:"binary,omitempty"`
+	ProcStart string `json:"proc_start,omitempty"`
+}
+
+// DaemonStatus describes the core found on cm's socket.
+type DaemonStatus struct {
+	// Info is nil when no pidfile exists or it is stale.
+	Info *DaemonInfo
+	// Healthy is true when something answers health checks on the socket,
+	// which may be a foreground core owned by another process.
+	Healthy bool
+	Engine  string
+}
+
+func (cm *ConfigManager) pidPath() string {
+	return filepath.Join(cm.HomeDir, "core.pid")
+}
+
+// lockCore serializes starting and stopping cores in cm's home directory.
+func (cm *ConfigManager) lockCore() (*fslock.Lock, error) {
+	return fslock.Acquire(filepath.Join(cm.HomeDir, "core.lock"))
+}
+
+// readDaemonInfo returns the pidfile contents, or nil if there is no live
+// daemon. Stale pidfiles, including ones whose PID now belongs to some other
+// process, are removed.
+func (cm *ConfigManager) readDaemonInfo() (*DaemonInfo, error) {
+	data, err := os.ReadFile(cm.pidPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var info DaemonInfo
+	if err := json.Unmarshal(data, &info); err != nil || !cm.isOurCore(&info) {
+		os.Remove(cm.pidPath())
+		return nil, nil
+	}
+	return &info, nil
+}
+
+// isOurCore reports whether info.PID is still the core that wrote the
+// pidfile. Where the process can't be inspected, or the pidfile predates
+// the identity fields, the core must answer on the socket with our token.
+func (cm *ConfigManager) isOurCore(info *DaemonInfo) bool {
+	if info.PID <= 0 || !processAlive(info.PID) {
+		return false
+	}
+	if exe, started, ok := processIdentity(info.PID); ok && info.ProcStart != "" {
+		return exe == info.Binary && started == info.ProcStart
+	}
+	_, err := Probe(context.Background(), cm)
+	return err == nil
+}
+
+func (cm *ConfigManager) writeDaemonInfo(info *DaemonInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cm.pidPath(), data, 0600)
+}
+
+// StartDaemon launches a detached core and records it in the pidfile. It
+// returns ErrDaemonRunning if a healthy core already owns the socket.
+func StartDaemon(ctx context.Context, cm *ConfigManager, port int) (*Manager, *DaemonInfo, error) {
+	lock, err := cm.lockCore()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer lock.Release()
+
+	return startDaemonLocked(ctx, cm, port)
+}
+
+func startDaemonLocked(ctx context.Context, cm *ConfigManager, port int) (*Manager, *DaemonInfo, error) {
+	if _, err := Probe(ctx, cm); err == nil {
+		info, _ := cm.readDaemonInfo()
+		return nil, info, ErrDaemonRunning
+	}
+
+	m := NewManager(cm, port)
+	m.Detach = true
+	if err := m.Start(ctx); err != nil {
+		m.Stop()
+		return nil, nil, err
+	}
+
+	info := &DaemonInfo{
+		PID:       m.pid,
+		Profile:   cm.Profile,
+		Port:      port,
+		Socket:    cm.GetSocketPath(),
+		Version:   Version,
+		StartedAt: time.Now(),
+		Binary:    m.BinaryPath,
+	}
+	if exe, started, ok := processIdentity(m.pid); ok {
+		info.Binary, info.ProcStart = exe, started
+	}
+	if err := cm.writeDaemonInfo(info); err != nil {
+		return m, info, fmt.Errorf("core started (pid %d) but pidfile could not be written: %w", m.pid, err)
+	}
+	return m, info, nil
+}
+
+// StopDaemon sends SIGTERM to the daemon recorded in the pidfile and escalates
+// to SIGKILL if it hasn't exited within grace.
+func StopDaemon(cm *ConfigManager, grace time.Duration) error {
+	lock, err := cm.lockCore()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	return stopDaemonLocked(cm, grace)
+}
+
+func stopDaemonLocked(cm *ConfigManager, grace time.Duration) error {
+	info, err := cm.readDaemonInfo()
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		return ErrDaemonNotRunning
+	}
+
+	proc, err := os.FindProcess(info.PID)
+	if err != nil {
+		return err
+	}
+	if err := terminate(proc, func() bool { return processAlive(info.PID) }, grace); err != nil {
+		return err
+	}
+	return os.Remove(cm.pidPath())
+}
+
+// RestartDaemon stops the running daemon, if any, and starts a fresh one while
+// holding the lock, so no other command can spawn a core in between.
+func RestartDaemon(ctx context.Context, cm *ConfigManager, port int, grace time.Duration) (*DaemonInfo, error) {
+	lock, err := cm.lockCore()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	if err := stopDaemonLocked(cm, grace); err != nil && !errors.Is(err, ErrDaemonNotRunning) {
+		return nil, err
+	}
+	_, info, err := startDaemonLocked(ctx, cm, port)
+	return info, err
+}
+
+// GetDaemonStatus inspects the pidfile and probes the socket.
+func GetDaemonStatus(ctx context.Context, cm *ConfigManager) (*DaemonStatus, error) {
+	info, err := cm.readDaemonInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &DaemonStatus{Info: info}
+	if client, err := Probe(ctx, cm); err == nil {
+		st.Healthy = true
+		if health, err := client.GetStatus(ctx); err == nil {
+			st.Engine = health.Engine
+		}
+	}
+	return st, nil
+}
+
+// terminate asks p to exit, then kills it if alive still reports true after
+// grace has elapsed.
+func terminate(p *os.Process, alive func() bool, grace time.Duration) error {
+	if err := askToExit(p); err != nil {
+		if !alive() {
+			return nil
+		}
+		return err
+	}
+
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if !alive() {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if err := p.Kill(); err != nil && alive() {
+		return err
+	}
+	return nil
+}
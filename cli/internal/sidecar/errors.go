@@ -0,0 +1,85 @@
+package sidecar
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrorKind classifies why a core request failed.
+type ErrorKind string
+
+const (
+	KindTransport         ErrorKind = "transport"
+	KindUnauthorized      ErrorKind = "unauthorized"
+	KindComplianceBlocked ErrorKind = "compliance-blocked"
+	KindNotFound          ErrorKind = "not-found"
+	KindInvalidRequest    ErrorKind = "invalid-request"
+	KindUpstreamFailure   ErrorKind = "upstream-failure"
+)
+
+// CoreError is returned by every CoreClient method when a request fails,
+// either before reaching the core (Transport) or with a non-2xx reply.
+type CoreError struct {
+	Op         string
+	StatusCode int
+	Message    string
+	Kind       ErrorKind
+	Err        error
+}
+
+func (e *CoreError) Error() string {
+	if e.Kind == KindTransport {
+		return fmt.Sprintf("%s: core unreachable: %v", e.Op, e.Err)
+	}
+	if e.Message == "" {
+		return fmt.Sprintf("%s failed: %s", e.Op, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%s failed: %s", e.Op, e.Message)
+}
+
+func (e *CoreError) Unwrap() error {
+	return e.Err
+}
+
+// IsKind reports whether err is a CoreError of the given kind.
+func IsKind(err error, kind ErrorKind) bool {
+	var ce *CoreError
+	return errors.As(err, &ce) && ce.Kind == kind
+}
+
+// checkResponse converts a resty result into a CoreError, or nil on success.
+func checkResponse(op string, resp *resty.Response, err error) error {
+	if err != nil {
+		return &CoreError{Op: op, Kind: KindTransport, Err: err}
+	}
+	if !resp.IsError() {
+		return nil
+	}
+
+	code := resp.StatusCode()
+	ce := &CoreError{
+		Op:         op,
+		StatusCode: code,
+		Message:    strings.TrimSpace(resp.String()),
+	}
+
+	switch {
+	case code == http.StatusUnauthorized:
+		ce.Kind = KindUnauthorized
+	case code == http.StatusForbidden:
+		// The auth middleware only ever answers 401, so a 403 is the
+		// Range Protocol firewall rejecting the destination.
+		ce.Kind = KindComplianceBlocked
+	case code == http.StatusNotFound:
+		ce.Kind = KindNotFound
+	case code >= 400 && code < 500:
+		ce.Kind = KindInvalidRequest
+	default:
+		ce.Kind = KindUpstreamFailure
+	}
+	return ce
+}
@@ -0,0 +1,67 @@
+package sidecar
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventKind identifies a core lifecycle transition.
+type EventKind int
+
+const (
+	EventStarted EventKind = iota
+	EventExited
+	EventRestarting
+	EventGaveUp
+)
+
+// Event is emitted by a supervising Manager whenever the core changes state.
+type Event struct {
+	Kind     EventKind
+	Time     time.Time
+	PID      int
+	ExitCode int
+	// Attempt counts restarts inside the current crash-loop window.
+	Attempt int
+	Delay   time.Duration
+	Err     error
+}
+
+func (e Event) String() string {
+	switch e.Kind {
+	case EventStarted:
+		return fmt.Sprintf("core started (pid %d)", e.PID)
+	case EventExited:
+		if e.Err != nil {
+			return fmt.Sprintf("core failed to launch: %v", e.Err)
+		}
+		return fmt.Sprintf("core exited with code %d", e.ExitCode)
+	case EventRestarting:
+		return fmt.Sprintf("restarting core in %s (attempt %d/%d)", e.Delay, e.Attempt, MaxRestarts)
+	case EventGaveUp:
+		return fmt.Sprintf("core crashed %d times within %s; giving up", e.Attempt, crashWindow)
+	}
+	return "unknown core event"
+}
+
+// Subscribe returns a channel of lifecycle events. Slow subscribers miss
+// events rather than stall the supervisor.
+func (m *Manager) Subscribe() <-chan Event {
+	ch := make(chan Event, 16)
+	m.mu.Lock()
+	m.subs = append(m.subs, ch)
+	m.mu.Unlock()
+	return ch
+}
+
+func (m *Manager) emit(e Event) {
+	e.Time = time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, ch := range m.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
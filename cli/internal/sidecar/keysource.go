@@ -0,0 +1,519 @@
+package sidecar
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/term"
+)
+
+// Names of the supported key sources, as stored in key.json.
+const (
+	KeySourceMachine    = "machine"
+	KeySourcePassphrase = "passphrase"
+	KeySourceEnv        = "env"
+	KeySourceKeyring    = "keyring"
+)
+
+// KeySources lists every key source name, in the order shown to users.
+var KeySources = []string{KeySourceMachine, KeySourcePassphrase, KeySourceEnv, KeySourceKeyring}
+
+// Environment variables read by key sources.
+const (
+	masterKeyEnv  = "SHADOWPRISM_MASTER_KEY"
+	passphraseEnv = "SHADOWPRISM_PASSPHRASE"
+)
+
+// Argon2id parameters for the passphrase source.
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+	saltSize     = 16
+)
+
+// keyCheckPlaintext is sealed into key.json so a wrong passphrase or master
+// key is caught before it produces garbage.
+const keyCheckPlaintext = "shadowprism-key-check"
+
+// KeySource derives the key that encrypts secrets in the config directory.
+type KeySource interface {
+	Name() string
+	Key() ([32]byte, error)
+}
+
+// keyConfig is persisted in key.json. Without it secrets are sealed with the
+// legacy machine key.
+type keyConfig struct {
+	Source string `json:"source"`
+	Salt   string `json:"salt,omitempty"`
+	Check  string `json:"check,omitempty"`
+}
+
+// machineKey derives a key from the hostname and platform. It needs no user
+// input but is guessable, so it is only the legacy default.
+type machineKey struct{}
+
+func (machineKey) Name() string { return KeySourceMachine }
+
+func (machineKey) Key() ([32]byte, error) {
+	hostname, _ := os.Hostname()
+	machineID := fmt.Sprintf("%s-%s-%s", hostname, runtime.GOOS, runtime.GOARCH)
+	return sha256.Sum256([]byte(machineID)), nil
+}
+
+// passphraseKey stretches a user passphrase with Argon2id.
+type passphraseKey struct {
+	salt []byte
+	// confirm asks for the passphrase twice when it is being chosen.
+	confirm bool
+}
+
+func (passphraseKey) Name() string { return KeySourcePassphrase }
+
+func (p passphraseKey) Key() ([32]byte, error) {
+	var key [32]byte
+	pass, err := readPassphrase(p.confirm)
+	if err != nil {
+		return key, err
+	}
+	copy(key[:], argon2.IDKey(pass, p.salt, argonTime, argonMemory, argonThreads, 32))
+	return key, nil
+}
+
+func readPassphrase(confirm bool) ([]byte, error) {
+	if pass := os.Getenv(passphraseEnv); pass != "" {
+		return []byte(pass), nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("secrets are passphrase-protected; set %s when not running in a terminal", passphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "🔑 ShadowPrism passphrase: ")
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(pass) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "🔑 Repeat passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(pass, again) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return pass, nil
+}
+
+// envKey reads a 32-byte master key, hex or base64 encoded, from
+// SHADOWPRISM_MASTER_KEY. It suits containers where secrets are injected.
+type envKey struct{}
+
+func (envKey) Name() string { return KeySourceEnv }
+
+func (envKey) Key() ([32]byte, error) {
+	var key [32]byte
+	raw := strings.TrimSpace(os.Getenv(masterKeyEnv))
+	if raw == "" {
+		return key, fmt.Errorf("%s is not set", masterKeyEnv)
+	}
+
+	decoded, err := hex.DecodeString(raw)
+	if err != nil {
+		decoded, err = base64.StdEncoding.DecodeString(raw)
+	}
+	if err != nil || len(decoded) != len(key) {
+		return key, fmt.Errorf("%s must be 32 bytes, hex or base64 encoded", masterKeyEnv)
+	}
+	copy(key[:], decoded)
+	return key, nil
+}
+
+// keyringKey keeps a random master key in the desktop keyring through the
+// Secret Service, using libsecret's secret-tool. Each profile has its own
+// entry.
+type keyringKey struct {
+	profile string
+	// create stores a new key when none is found.
+	create bool
+}
+
+// legacyKeyringAttrs is the entry used before profiles had their own. Only
+// the default profile can have been sealed with it.
+var legacyKeyringAttrs = []string{"service", "shadowprism", "account", "master-key"}
+
+// keyringAttrs names profile's keyring entry. The pending entry holds a
+// rotated key until every secret has been re-encrypted under it.
+func keyringAttrs(profile string, pending bool) []string {
+	account := "master-key"
+	if pending {
+		account = "master-key-pending"
+	}
+	return []string{"service", "shadowprism", "profile", profile, "account", account}
+}
+
+func (keyringKey) Name() string { return KeySourceKeyring }
+
+func (k keyringKey) Key() ([32]byte, error) {
+	if err := checkSecretTool(); err != nil {
+		return [32]byte{}, err
+	}
+	attrs := keyringAttrs(k.profile, false)
+	key, err := keyringLookup(attrs)
+	if err == nil || !errors.Is(err, errNoKeyringEntry) {
+		return key, err
+	}
+	if k.profile == DefaultProfile {
+		if legacy, lerr := keyringLookup(legacyKeyringAttrs); lerr == nil {
+			// Moving it is best effort; the legacy entry stays readable.
+			keyringStore(attrs, legacy)
+			return legacy, nil
+		}
+	}
+	if !k.create {
+		return key, err
+	}
+	if _, err := rand.Read(key[:]); err != nil {
+		return key, err
+	}
+	return key, keyringStore(attrs, key)
+}
+
+// rotatingKeyringKey stages a fresh random key in profile's pending entry.
+// The live entry keeps the old key until SetKeySource commits the new one,
+// so a failed rotation never loses the key the secrets are sealed with.
+type rotatingKeyringKey struct {
+	profile string
+	staged  *[32]byte
+}
+
+func (*rotatingKeyringKey) Name() string { return KeySourceKeyring }
+
+func (r *rotatingKeyringKey) Key() ([32]byte, error) {
+	var key [32]byte
+	if err := checkSecretTool(); err != nil {
+		return key, err
+	}
+	if _, err := rand.Read(key[:]); err != nil {
+		return key, err
+	}
+	if err := keyringStore(keyringAttrs(r.profile, true), key); err != nil {
+		return key, err
+	}
+	r.staged = &key
+	return key, nil
+}
+
+// commit makes the staged key the live one. If that fails, the old key is
+// written back in case the keyring dropped it.
+func (r *rotatingKeyringKey) commit() error {
+	live := keyringAttrs(r.profile, false)
+	old, oldErr := keyringLookup(live)
+	if err := keyringStore(live, *r.staged); err != nil {
+		if oldErr == nil {
+			keyringStore(live, old)
+		}
+		return err
+	}
+	keyringClear(keyringAttrs(r.profile, true))
+	return nil
+}
+
+func (r *rotatingKeyringKey) discard() {
+	keyringClear(keyringAttrs(r.profile, true))
+}
+
+// stagedKeySource is a source whose Key only stages a new key. SetKeySource
+// commits it once every secret and key.json are rewritten, and discards it
+// if anything fails before then.
+type stagedKeySource interface {
+	KeySource
+	commit() error
+	discard()
+}
+
+var errNoKeyringEntry = errors.New("no shadowprism master key in the keyring")
+
+func checkSecretTool() error {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return errors.New("keyring support needs secret-tool (libsecret) and a running Secret Service")
+	}
+	return nil
+}
+
+func keyringLookup(attrs []string) ([32]byte, error) {
+	var key [32]byte
+	out, err := exec.Command("secret-tool", append([]string{"lookup"}, attrs...)...).Output()
+	if err != nil {
+		return key, fmt.Errorf("%w: %v", errNoKeyringEntry, err)
+	}
+	decoded, err := hex.DecodeString(strings.TrimSpace(string(out)))
+	if err != nil || len(decoded) != len(key) {
+		return key, errors.New("keyring entry for shadowprism is malformed")
+	}
+	copy(key[:], decoded)
+	return key, nil
+}
+
+func keyringStore(attrs []string, key [32]byte) error {
+	store := exec.Command("secret-tool", append([]string{"store", "--label=ShadowPrism master key"}, attrs...)...)
+	store.Stdin = strings.NewReader(hex.EncodeToString(key[:]))
+	if out, err := store.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to store key in keyring: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func keyringClear(attrs []string) error {
+	return exec.Command("secret-tool", append([]string{"clear"}, attrs...)...).Run()
+}
+
+// NewKeySource returns a source for setting up name from scratch: passphrases
+// get a fresh salt and are confirmed, and the keyring gets a new key for this
+// profile if it doesn't hold one yet.
+func (cm *ConfigManager) NewKeySource(name string) (KeySource, error) {
+	switch name {
+	case KeySourceMachine:
+		return machineKey{}, nil
+	case KeySourcePassphrase:
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		return passphraseKey{salt: salt, confirm: true}, nil
+	case KeySourceEnv:
+		return envKey{}, nil
+	case KeySourceKeyring:
+		return keyringKey{profile: cm.Profile, create: true}, nil
+	}
+	return nil, fmt.Errorf("unknown key source %q (want one of %s)", name, strings.Join(KeySources, ", "))
+}
+
+// sourceFor rebuilds the source recorded in cfg.
+func (cm *ConfigManager) sourceFor(cfg *keyConfig) (KeySource, error) {
+	switch cfg.Source {
+	case KeySourcePassphrase:
+		salt, err := hex.DecodeString(cfg.Salt)
+		if err != nil || len(salt) == 0 {
+			return nil, errors.New("key.json has no valid passphrase salt")
+		}
+		return passphraseKey{salt: salt}, nil
+	case KeySourceKeyring:
+		return keyringKey{profile: cm.Profile}, nil
+	}
+	return cm.NewKeySource(cfg.Source)
+}
+
+func (cm *ConfigManager) keyConfigPath() string {
+	return filepath.Join(cm.HomeDir, "key.json")
+}
+
+// loadKeyConfig reads key.json. Without one, secrets are sealed with the
+// legacy machine key, whatever the environment holds: only key.json selects
+// the env source, so setting the variable to migrate can't lock out the
+// secrets it is migrating.
+func (cm *ConfigManager) loadKeyConfig() (*keyConfig, error) {
+	data, err := os.ReadFile(cm.keyConfigPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return &keyConfig{Source: KeySourceMachine}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg keyConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", cm.keyConfigPath(), err)
+	}
+	return &cfg, nil
+}
+
+// KeySourceName reports which key source protects secrets.
+func (cm *ConfigManager) KeySourceName() (string, error) {
+	cfg, err := cm.loadKeyConfig()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Source, nil
+}
+
+// unlockKey resolves the configured key source and checks the key against
+// the value sealed in key.json.
+func (cm *ConfigManager) unlockKey() ([32]byte, error) {
+	var key [32]byte
+	cfg, err := cm.loadKeyConfig()
+	if err != nil {
+		return key, err
+	}
+	src, err := cm.sourceFor(cfg)
+	if err != nil {
+		return key, err
+	}
+	if key, err = src.Key(); err != nil {
+		return key, err
+	}
+
+	if cfg.Check == "" || checkKey(&key, cfg.Check) {
+		return key, nil
+	}
+	// A rotation that stopped after rewriting the secrets but before
+	// committing the keyring entry leaves the new key pending.
+	if cfg.Source == KeySourceKeyring {
+		pendingAttrs := keyringAttrs(cm.Profile, true)
+		if pending, err := keyringLookup(pendingAttrs); err == nil && checkKey(&pending, cfg.Check) {
+			if err := keyringStore(keyringAttrs(cm.Profile, false), pending); err == nil {
+				keyringClear(pendingAttrs)
+			}
+			return pending, nil
+		}
+	}
+	return key, fmt.Errorf("wrong key for %s-protected secrets", cfg.Source)
+}
+
+func checkKey(key *[32]byte, check string) bool {
+	got, err := openSecret(key, []byte(check))
+	return err == nil && got == keyCheckPlaintext
+}
+
+// SetKeySource re-encrypts every stored secret under src and records it as
+// the active key source. It returns the number of secrets migrated. If any
+// step fails, the secrets and key.json already written are restored, and a
+// staged keyring key is dropped without touching the live one.
+func (cm *ConfigManager) SetKeySource(src KeySource) (int, error) {
+	oldKey, err := cm.secretKey()
+	if err != nil {
+		return 0, err
+	}
+	oldConfig, err := os.ReadFile(cm.keyConfigPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(cm.HomeDir, "*.enc"))
+	if err != nil {
+		return 0, err
+	}
+
+	// Decrypt everything up front so a bad file aborts before any writes.
+	type secretFile struct {
+		path  string
+		old   []byte
+		value string
+	}
+	var files []secretFile
+	for _, path := range paths {
+		if filepath.Base(path) == authTokenSecret+".enc" {
+			// Always sealed with the machine key; see saveAuthToken.
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		value, err := openSecret(&oldKey, data)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		files = append(files, secretFile{path: path, old: data, value: value})
+	}
+
+	newKey, err := src.Key()
+	if err != nil {
+		return 0, err
+	}
+	staged, _ := src.(stagedKeySource)
+	if staged != nil {
+		defer func() {
+			if err != nil {
+				staged.discard()
+			}
+		}()
+	}
+	check, err := sealSecret(&newKey, keyCheckPlaintext)
+	if err != nil {
+		return 0, err
+	}
+	cfg := keyConfig{Source: src.Name(), Check: string(check)}
+	if p, ok := src.(passphraseKey); ok {
+		cfg.Salt = hex.EncodeToString(p.salt)
+	}
+
+	restore := func(n int) {
+		for _, f := range files[:n] {
+			writeFileAtomic(f.path, f.old)
+		}
+	}
+	for i, f := range files {
+		var sealed []byte
+		sealed, err = sealSecret(&newKey, f.value)
+		if err == nil {
+			err = writeFileAtomic(f.path, sealed)
+		}
+		if err != nil {
+			restore(i)
+			return 0, fmt.Errorf("failed to re-encrypt %s: %w", filepath.Base(f.path), err)
+		}
+	}
+
+	var data []byte
+	data, err = json.MarshalIndent(cfg, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(cm.keyConfigPath(), data)
+	}
+	if err != nil {
+		restore(len(files))
+		return 0, fmt.Errorf("failed to record key source: %w", err)
+	}
+
+	if staged != nil {
+		if err = staged.commit(); err != nil {
+			restore(len(files))
+			if oldConfig != nil {
+				writeFileAtomic(cm.keyConfigPath(), oldConfig)
+			} else {
+				os.Remove(cm.keyConfigPath())
+			}
+			return 0, fmt.Errorf("failed to store the new key in the keyring: %w", err)
+		}
+	}
+
+	cm.keyMu.Lock()
+	cm.key = &newKey
+	cm.keyMu.Unlock()
+	return len(files), nil
+}
+
+// writeFileAtomic replaces path with a 0600 file via a rename, so a crash
+// never leaves a truncated secret behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
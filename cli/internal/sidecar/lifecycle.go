@@ -0,0 +1,125 @@
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// probeTimeout bounds the health check used to decide whether a core is
+// already listening on the socket.
+const probeTimeout = 1500 * time.Millisecond
+
+// EnsureOptions controls how EnsureCore spawns a core when none is running.
+type EnsureOptions struct {
+	Port         int
+	StartTimeout time.Duration
+	// Detach leaves a spawned core running after the caller exits.
+	Detach bool
+}
+
+// Session is a connection to a healthy core, either attached to one that was
+// already running or spawned on behalf of the caller.
+type Session struct {
+	Client  *CoreClient
+	Manager *Manager
+	Spawned bool
+	cm      *ConfigManager
+}
+
+// Close stops the core if this session spawned it in the foreground. Attached
+// and detached cores are left running.
+func (s *Session) Close() error {
+	if s.Manager == nil || s.Manager.Detach {
+		return nil
+	}
+	return s.Manager.Stop()
+}
+
+// Events subscribes to lifecycle events of a core this session supervises.
+// It returns nil for attached and detached cores, which nobody here watches.
+func (s *Session) Events() <-chan Event {
+	if s.Manager == nil || s.Manager.Detach {
+		return nil
+	}
+	return s.Manager.Subscribe()
+}
+
+// Logs returns the in-memory buffer of a core this session runs in the
+// foreground, and the log file on disk otherwise.
+func (s *Session) Logs() LogSource {
+	if s.Manager != nil && s.Manager.Logs() != nil {
+		return s.Manager.Logs()
+	}
+	return LogFile(s.cm.LogPath())
+}
+
+// Probe reports whether a core is answering on cm's socket with the
+// persisted token. A nil error means it is healthy.
+func Probe(ctx context.Context, cm *ConfigManager) (*CoreClient, error) {
+	client, err := NewClient(cm)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	if _, err := client.GetStatus(ctx); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// EnsureCore attaches to a running core, or spawns one via Manager if nothing
+// is listening.
+func EnsureCore(ctx context.Context, cm *ConfigManager, opts EnsureOptions) (*Session, error) {
+	client, err := Probe(ctx, cm)
+	if err == nil {
+		return &Session{Client: client, cm: cm}, nil
+	}
+
+	// Something is listening but rejects our token; spawning a second core
+	// would steal its socket out from under the other user of it.
+	if IsKind(err, KindUnauthorized) {
+		return nil, fmt.Errorf("a core is running on %s but rejected the stored auth token; stop it before starting a new one", cm.GetSocketPath())
+	}
+	if !errors.Is(err, ErrNoAuthToken) && !IsKind(err, KindTransport) {
+		return nil, err
+	}
+
+	// Serialize with other commands spawning at the same moment, then check
+	// again in case one of them won the race.
+	lock, err := cm.lockCore()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	if client, err := Probe(ctx, cm); err == nil {
+		return &Session{Client: client, cm: cm}, nil
+	}
+
+	startCtx, cancel := context.WithTimeout(ctx, opts.StartTimeout)
+	defer cancel()
+
+	var m *Manager
+	if opts.Detach {
+		m, _, err = startDaemonLocked(startCtx, cm, opts.Port)
+	} else {
+		m = NewManager(cm, opts.Port)
+		if err = m.Start(startCtx); err != nil {
+			m.Stop()
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to start core engine: %w", err)
+	}
+
+	return &Session{
+		Client:  NewCoreClient(cm.GetSocketPath(), m.AuthToken),
+		Manager: m,
+		Spawned: true,
+		cm:      cm,
+	}, nil
+}
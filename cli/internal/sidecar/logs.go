@@ -0,0 +1,286 @@
+package sidecar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Core log rotation limits. The active file is rotated once it would grow
+// past logMaxSize, keeping logMaxBackups older files (core.log.1 is newest).
+const (
+	logMaxSize    = 5 << 20
+	logMaxBackups = 3
+	logRingLines  = 500
+)
+
+// LogDir is where core output is written; install.sh creates it up front.
+func (cm *ConfigManager) LogDir() string {
+	return filepath.Join(cm.HomeDir, "logs")
+}
+
+// LogPath is the active core log file.
+func (cm *ConfigManager) LogPath() string {
+	return filepath.Join(cm.LogDir(), "core.log")
+}
+
+// LogSource yields the most recent core log lines, oldest first.
+type LogSource interface {
+	Tail(n int) ([]string, error)
+}
+
+// rotateLog shifts path to path.1, path.1 to path.2 and so on, dropping the
+// oldest backup.
+func rotateLog(path string, backups int) error {
+	os.Remove(fmt.Sprintf("%s.%d", path, backups))
+	for i := backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	if err := os.Rename(path, path+".1"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// openLogAppend opens path for appending, rotating it first if it is already
+// over the size limit.
+func openLogAppend(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Size() >= logMaxSize {
+		if err := rotateLog(path, logMaxBackups); err != nil {
+			return nil, err
+		}
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+}
+
+// rotatingFile is an io.WriteCloser that rotates its file by size.
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	size int64
+}
+
+func openRotatingFile(path string) (*rotatingFile, error) {
+	f, err := openLogAppend(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &rotatingFile{path: path, f: f, size: fi.Size()}, nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+
+	if r.size > 0 && r.size+int64(len(p)) > logMaxSize {
+		r.f.Close()
+		if err := rotateLog(r.path, logMaxBackups); err != nil {
+			r.f = nil
+			return 0, err
+		}
+		f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			r.f = nil
+			return 0, err
+		}
+		r.f, r.size = f, 0
+	}
+
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// LogWriterCommand is the hidden "core" subcommand that runs ForwardLog. A
+// detached core outlives the command that started it, so its output goes
+// through this small process, which keeps rotating the log for as long as
+// the core runs.
+const LogWriterCommand = "log-writer"
+
+// startLogWriter starts a detached log writer appending r to path.
+func startLogWriter(r *os.File, path string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "core", LogWriterCommand, path)
+	cmd.Stdin = r
+	cmd.SysProcAttr = detachAttr()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// ForwardLog copies r to the rotating log at path until r is closed. The
+// core must never block on its log, so output that can't be written is
+// dropped and the file is reopened on the next write.
+func ForwardLog(r io.Reader, path string) error {
+	var out *rotatingFile
+	defer func() {
+		if out != nil {
+			out.Close()
+		}
+	}()
+
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if out == nil {
+				out, _ = openRotatingFile(path)
+			}
+			if out != nil {
+				if _, werr := out.Write(buf[:n]); werr != nil {
+					out.Close()
+					out = nil
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// LogRing keeps the last lines written to it in memory.
+type LogRing struct {
+	mu      sync.Mutex
+	lines   []string
+	next    int
+	full    bool
+	partial []byte
+}
+
+func NewLogRing(size int) *LogRing {
+	return &LogRing{lines: make([]string, size)}
+}
+
+func (r *LogRing) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data := append(r.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		r.push(strings.TrimRight(string(data[:i]), "\r"))
+		data = data[i+1:]
+	}
+	r.partial = append(r.partial[:0], data...)
+	return len(p), nil
+}
+
+func (r *LogRing) push(line string) {
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Tail returns up to n of the most recent complete lines.
+func (r *LogRing) Tail(n int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.lines)
+	}
+	if n <= 0 || n > count {
+		n = count
+	}
+
+	out := make([]string, n)
+	start := r.next - n
+	for i := range out {
+		out[i] = r.lines[(start+i+len(r.lines))%len(r.lines)]
+	}
+	return out, nil
+}
+
+// LogFile reads lines from a core log on disk.
+type LogFile string
+
+// tailChunk is how much of the end of a log file Tail reads per step.
+const tailChunk = 64 << 10
+
+// Tail returns up to n of the last lines in the file. A missing file yields
+// no lines.
+func (path LogFile) Tail(n int) ([]string, error) {
+	f, err := os.Open(string(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Read backwards until enough newlines have been seen.
+	var buf []byte
+	offset := fi.Size()
+	for offset > 0 && (n <= 0 || bytes.Count(buf, []byte{'\n'}) <= n) {
+		step := min(int64(tailChunk), offset)
+		offset -= step
+		chunk := make([]byte, step)
+		if _, err := f.ReadAt(chunk, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		buf = append(chunk, buf...)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(buf), "\n"), "\n")
+	if offset > 0 {
+		// The first line is probably cut in half.
+		lines = lines[1:]
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, nil
+	}
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
@@ -2,85 +2,328 @@ package sidecar
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/nathfavour/shadowprism/cli/internal/embed"
 )
 
+// Crash supervision limits. A core that dies more than MaxRestarts times
+// within crashWindow is left down.
+const (
+	MaxRestarts       = 5
+	crashWindow       = time.Minute
+	restartBackoffMin = 1 * time.Second
+	restartBackoffMax = 30 * time.Second
+	restartReadyWait  = 10 * time.Second
+)
+
+var errStopping = errors.New("manager is stopping")
+
 type Manager struct {
-        BinaryPath string
-        Port       int
-        AuthToken  string
-        cmd        *exec.Cmd
-        client     *resty.Client
+	BinaryPath string
+	Port       int
+	AuthToken  string
+	// Detach starts the core in its own session so it outlives this process.
+	Detach bool
+	cm     *ConfigManager
+	client *resty.Client
+	// logs keeps recent output of a foreground core; logFile persists it.
+	logs    *LogRing
+	logFile io.WriteCloser
+	// env is added to the core's environment; see ConfigManager.CoreEnv.
+	env []string
+
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	pid       int
+	exited    chan struct{} // closed once the current child has been reaped
+	startedAt time.Time
+	stopping  bool
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	subs      []chan Event
 }
 
-func NewManager(port int, token string) *Manager {
-        return &Manager{
-                Port:       port,
-                AuthToken:  token,
-                client:     resty.New().SetTimeout(2 * time.Second),
-        }
+func NewManager(cm *ConfigManager, port int) *Manager {
+	return &Manager{
+		Port:   port,
+		cm:     cm,
+		client: resty.New().SetTimeout(2 * time.Second),
+		stopCh: make(chan struct{}),
+	}
 }
 
+// Start launches the core and blocks until it answers health checks or ctx
+// is done. Foreground cores are then supervised and restarted if they crash.
 func (m *Manager) Start(ctx context.Context) error {
-        // Extract the embedded binary
-        binPath, err := embed.ExtractCore()
-        if err != nil {
-                return fmt.Errorf("failed to extract embedded core: %w", err)
-        }
-        m.BinaryPath = binPath
-
-        m.cmd = exec.CommandContext(ctx, m.BinaryPath)
-        m.cmd.Env = append(os.Environ(), 
-                fmt.Sprintf("SHADOWPRISM_AUTH_TOKEN=%s", m.AuthToken),
-                fmt.Sprintf("PORT=%d", m.Port),
-        )
-	// Redirect logs for debugging (could be piped to a TUI buffer later)
-	m.cmd.Stdout = os.Stdout
-	m.cmd.Stderr = os.Stderr
-
-	if err := m.cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start core: %w", err)
+	// Extract the embedded binary
+	binPath, err := embed.ExtractCore()
+	if err != nil {
+		return fmt.Errorf("failed to extract embedded core: %w", err)
 	}
+	m.BinaryPath = binPath
 
-	// Wait for health check
-	return m.waitForReady(ctx)
-}
+	// Credentials go into the child's environment only, never ours.
+	env, err := m.cm.CoreEnv()
+	if err != nil {
+		return err
+	}
+	m.env = m.env[:0]
+	for _, v := range env {
+		m.env = append(m.env, v.Name+"="+v.Value)
+	}
 
-func (m *Manager) waitForReady(ctx context.Context) error {
-	cm, err := NewConfigManager()
+	// Every launch gets a fresh token. It is persisted encrypted (0600) so
+	// other commands run by the same user can attach to this core.
+	token, err := GenerateAuthToken()
 	if err != nil {
 		return err
 	}
-	client := NewCoreClient(cm.GetSocketPath(), m.AuthToken)
+	if err := m.cm.saveAuthToken(token); err != nil {
+		return fmt.Errorf("failed to persist auth token: %w", err)
+	}
+	m.AuthToken = token
+
+	if !m.Detach {
+		f, err := openRotatingFile(m.cm.LogPath())
+		if err != nil {
+			return fmt.Errorf("failed to open core log: %w", err)
+		}
+		m.logFile = f
+		m.logs = NewLogRing(logRingLines)
+	}
+
+	if err := m.launch(); err != nil {
+		return err
+	}
+
+	// Wait for health check
+	if err := m.waitForReady(ctx); err != nil {
+		return err
+	}
+	m.emit(Event{Kind: EventStarted, PID: m.pid})
+
+	// A detached core outlives us, so there is nothing for us to supervise.
+	if !m.Detach {
+		go m.supervise()
+	}
+	return nil
+}
+
+// launch starts a core process and a goroutine that reaps it. ctx only
+// bounds startup elsewhere; the core lives until Stop is called.
+func (m *Manager) launch() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopping {
+		return errStopping
+	}
+
+	cmd := exec.Command(m.BinaryPath)
+	cmd.Env = append(os.Environ(), m.env...)
+	cmd.Env = append(cmd.Env,
+		fmt.Sprintf("SHADOWPRISM_AUTH_TOKEN=%s", m.AuthToken),
+		fmt.Sprintf("PORT=%d", m.Port),
+		fmt.Sprintf("SHADOWPRISM_DATA_DIR=%s", m.cm.HomeDir),
+		// Output goes to a log file, never a terminal.
+		"NO_COLOR=1",
+	)
+	if m.Detach {
+		// A detached core must not hold our terminal, and it outlives any
+		// pipe we could read, so a detached log writer rotates its output.
+		cmd.SysProcAttr = detachAttr()
+		r, w, err := os.Pipe()
+		if err != nil {
+			return fmt.Errorf("failed to open core log: %w", err)
+		}
+		err = startLogWriter(r, m.cm.LogPath())
+		r.Close()
+		if err != nil {
+			w.Close()
+			return fmt.Errorf("failed to start core log writer: %w", err)
+		}
+		defer w.Close()
+		cmd.Stdout = w
+		cmd.Stderr = w
+	} else {
+		// Keep core output off our terminal; it would corrupt the TUI.
+		out := io.MultiWriter(m.logFile, m.logs)
+		cmd.Stdout = out
+		cmd.Stderr = out
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start core: %w", err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	m.cmd = cmd
+	m.pid = cmd.Process.Pid
+	m.exited = exited
+	m.startedAt = time.Now()
+	return nil
+}
+
+func (m *Manager) waitForReady(ctx context.Context) error {
+	client := NewCoreClient(m.cm.GetSocketPath(), m.AuthToken)
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
+	m.mu.Lock()
+	exited := m.exited
+	m.mu.Unlock()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-exited:
+			return fmt.Errorf("core process exited prematurely")
 		case <-ticker.C:
-			_, err := client.GetStatus()
+			_, err := client.GetStatus(ctx)
 			if err == nil {
 				return nil
 			}
-			// Check if process died early
-			if m.cmd.ProcessState != nil && m.cmd.ProcessState.Exited() {
-				return fmt.Errorf("core process exited prematurely")
+		}
+	}
+}
+
+// supervise waits for the core to exit and relaunches it with exponential
+// backoff until Stop is called or the crash-loop limit is hit.
+func (m *Manager) supervise() {
+	var crashes []time.Time
+	delay := restartBackoffMin
+	var launchErr error
+
+	for {
+		if launchErr == nil {
+			m.mu.Lock()
+			cmd, exited, startedAt := m.cmd, m.exited, m.startedAt
+			m.mu.Unlock()
+
+			<-exited
+			if m.isStopping() {
+				return
+			}
+			m.emit(Event{Kind: EventExited, PID: cmd.Process.Pid, ExitCode: cmd.ProcessState.ExitCode()})
+
+			// A core that stayed up for a while earns a fresh backoff.
+			if time.Since(startedAt) > crashWindow {
+				delay = restartBackoffMin
+			}
+		}
+
+		now := time.Now()
+		recent := crashes[:0]
+		for _, t := range crashes {
+			if now.Sub(t) < crashWindow {
+				recent = append(recent, t)
 			}
 		}
+		crashes = append(recent, now)
+
+		if len(crashes) > MaxRestarts {
+			m.emit(Event{Kind: EventGaveUp, Attempt: len(crashes)})
+			return
+		}
+
+		m.emit(Event{Kind: EventRestarting, Attempt: len(crashes), Delay: delay})
+		select {
+		case <-m.stopCh:
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, restartBackoffMax)
+
+		launchErr = m.relaunch()
+		switch {
+		case m.isStopping():
+			return
+		case launchErr != nil:
+			m.emit(Event{Kind: EventExited, Err: launchErr})
+		default:
+			m.emit(Event{Kind: EventStarted, PID: m.PID()})
+		}
 	}
 }
 
-func (m *Manager) Stop() error {
-	if m.cmd != nil && m.cmd.Process != nil {
-		return m.cmd.Process.Kill()
+func (m *Manager) relaunch() error {
+	if err := m.launch(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), restartReadyWait)
+	defer cancel()
+	if err := m.waitForReady(ctx); err != nil {
+		// An unhealthy core is killed and counted as another crash.
+		m.mu.Lock()
+		proc := m.cmd.Process
+		m.mu.Unlock()
+		proc.Kill()
+		return err
 	}
 	return nil
 }
+
+// Logs returns recent output of a foreground core, or nil for a detached one.
+func (m *Manager) Logs() *LogRing {
+	return m.logs
+}
+
+// PID returns the process id of the current core.
+func (m *Manager) PID() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pid
+}
+
+func (m *Manager) isStopping() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stopping
+}
+
+// stopGrace is how long a foreground core gets to exit after SIGTERM.
+const stopGrace = 5 * time.Second
+
+// Stop halts supervision and shuts the core down, gracefully if it
+// cooperates. Running detached cores are normally stopped with StopDaemon.
+func (m *Manager) Stop() error {
+	m.mu.Lock()
+	m.stopping = true
+	cmd, exited := m.cmd, m.exited
+	m.mu.Unlock()
+	m.stopOnce.Do(func() { close(m.stopCh) })
+
+	if m.logFile != nil {
+		defer m.logFile.Close()
+	}
+
+	if cmd == nil || cmd.Process == nil {
+		return nil
+	}
+	alive := func() bool {
+		select {
+		case <-exited:
+			return false
+		default:
+			return true
+		}
+	}
+	if !alive() {
+		return nil
+	}
+	return terminate(cmd.Process, alive, stopGrace)
+}
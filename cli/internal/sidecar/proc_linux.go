@@ -0,0 +1,37 @@
+//go:build linux
+
+package sidecar
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// processIdentity returns the executable and kernel start time of pid, which
+// together tell a process apart from a later one that reused its PID.
+func processIdentity(pid int) (exe, started string, ok bool) {
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return "", "", false
+	}
+	// The core binary is re-extracted on upgrade, leaving older cores
+	// pointing at the unlinked file.
+	exe = strings.TrimSuffix(exe, " (deleted)")
+
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", "", false
+	}
+	// The command name may contain spaces and parentheses, so fields are
+	// counted from the last ')'. Start time is field 22; state is field 3.
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return "", "", false
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 20 {
+		return "", "", false
+	}
+	return exe, fields[19], true
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package sidecar
+
+// processIdentity is only available where /proc is; callers fall back to
+// asking the core itself.
+func processIdentity(pid int) (exe, started string, ok bool) {
+	return "", "", false
+}
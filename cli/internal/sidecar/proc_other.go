@@ -0,0 +1,22 @@
+//go:build !unix
+
+package sidecar
+
+import (
+	"os"
+	"syscall"
+)
+
+func detachAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+func processAlive(pid int) bool {
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
+
+// askToExit has no graceful equivalent without signals, so it kills outright.
+func askToExit(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package sidecar
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// detachAttr puts the core in a new session so it survives our exit and
+// doesn't receive the terminal's SIGINT.
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// processAlive reports whether pid exists, even if owned by another user.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// askToExit requests a graceful shutdown.
+func askToExit(p *os.Process) error {
+	return p.Signal(syscall.SIGTERM)
+}
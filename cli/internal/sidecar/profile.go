@@ -0,0 +1,217 @@
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultProfile lives directly in ~/.shadowprism, as before profiles existed.
+const DefaultProfile = "default"
+
+const profileEnv = "SHADOWPRISM_PROFILE"
+
+// Profile selects the profile for this process, for --profile. It wins over
+// SHADOWPRISM_PROFILE and the profile chosen with `profile use`.
+var Profile string
+
+var (
+	ErrProfileNotFound = errors.New("profile does not exist")
+	ErrProfileExists   = errors.New("profile already exists")
+)
+
+var profileNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// rootDir is ~/.shadowprism, shared by all profiles.
+func rootDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".shadowprism"), nil
+}
+
+func profileDir(root, name string) string {
+	if name == DefaultProfile {
+		return root
+	}
+	return filepath.Join(root, "profiles", name)
+}
+
+func activeProfilePath(root string) string {
+	return filepath.Join(root, "active_profile")
+}
+
+func validateProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use up to 32 lowercase letters, digits, '-' or '_'", name)
+	}
+	return nil
+}
+
+// ActiveProfile resolves the profile in effect: --profile, then
+// SHADOWPRISM_PROFILE, then the one saved by UseProfile.
+func ActiveProfile() (string, error) {
+	if Profile != "" {
+		return Profile, nil
+	}
+	if name := os.Getenv(profileEnv); name != "" {
+		return name, nil
+	}
+	return SavedProfile()
+}
+
+// SavedProfile is the profile chosen with UseProfile.
+func SavedProfile() (string, error) {
+	root, err := rootDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(activeProfilePath(root))
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultProfile, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if name := strings.TrimSpace(string(data)); name != "" {
+		return name, nil
+	}
+	return DefaultProfile, nil
+}
+
+// OpenProfile returns a ConfigManager rooted in an existing profile.
+func OpenProfile(name string) (*ConfigManager, error) {
+	if err := validateProfileName(name); err != nil {
+		return nil, err
+	}
+	root, err := rootDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := profileDir(root, name)
+	if name == DefaultProfile {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+	} else if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q (create it with `shadowprism profile create %s`)", ErrProfileNotFound, name, name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &ConfigManager{HomeDir: dir, Profile: name}, nil
+}
+
+// CreateProfile makes an empty profile directory.
+func CreateProfile(name string) (*ConfigManager, error) {
+	if err := validateProfileName(name); err != nil {
+		return nil, err
+	}
+	root, err := rootDir()
+	if err != nil {
+		return nil, err
+	}
+	if name == DefaultProfile {
+		return nil, fmt.Errorf("%w: %q", ErrProfileExists, name)
+	}
+
+	dir := profileDir(root, name)
+	if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.Mkdir(dir, 0700); errors.Is(err, os.ErrExist) {
+		return nil, fmt.Errorf("%w: %q", ErrProfileExists, name)
+	} else if err != nil {
+		return nil, err
+	}
+	return &ConfigManager{HomeDir: dir, Profile: name}, nil
+}
+
+// ListProfiles returns the default profile followed by named ones, sorted.
+func ListProfiles() ([]string, error) {
+	root, err := rootDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, "profiles"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && profileNameRe.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...), nil
+}
+
+// UseProfile makes name the profile used when neither --profile nor
+// SHADOWPRISM_PROFILE is given.
+func UseProfile(name string) error {
+	if _, err := OpenProfile(name); err != nil {
+		return err
+	}
+	root, err := rootDir()
+	if err != nil {
+		return err
+	}
+	if name == DefaultProfile {
+		err := os.Remove(activeProfilePath(root))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return os.WriteFile(activeProfilePath(root), []byte(name+"\n"), 0600)
+}
+
+// DeleteProfile removes a named profile with its secrets, wallet and
+// database. It refuses while a core for the profile is running.
+func DeleteProfile(ctx context.Context, name string) error {
+	if name == DefaultProfile {
+		return errors.New("the default profile cannot be deleted")
+	}
+	cm, err := OpenProfile(name)
+	if err != nil {
+		return err
+	}
+
+	lock, err := cm.lockCore()
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	if info, _ := cm.readDaemonInfo(); info != nil {
+		return fmt.Errorf("profile %q has a running core (pid %d); stop it first", name, info.PID)
+	}
+	if _, err := Probe(ctx, cm); err == nil {
+		return fmt.Errorf("profile %q has a running core; stop it first", name)
+	}
+
+	if saved, _ := SavedProfile(); saved == name {
+		if err := UseProfile(DefaultProfile); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(cm.HomeDir); err != nil {
+		return err
+	}
+	// The profile's keyring key can't decrypt anything any more.
+	if checkSecretTool() == nil {
+		keyringClear(keyringAttrs(name, false))
+		keyringClear(keyringAttrs(name, true))
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package sidecar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/internal/fslock"
+)
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+var secretNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// SecretInfo is the audit record kept for each stored secret. Names and
+// timestamps are not secret; values never appear here.
+type SecretInfo struct {
+	Name       string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	RevealedAt *time.Time `json:"revealed_at,omitempty"`
+}
+
+// ValidateSecretName rejects names that aren't safe as file names.
+func ValidateSecretName(name string) error {
+	if !secretNameRe.MatchString(name) || strings.HasSuffix(name, ".tmp") {
+		return fmt.Errorf("invalid secret name %q: use letters, digits, '_', '.' or '-'", name)
+	}
+	if isInternalSecret(name) {
+		return fmt.Errorf("secret name %q is reserved", name)
+	}
+	return nil
+}
+
+// isInternalSecret reports whether name is managed by ShadowPrism itself and
+// kept out of the user's secret list.
+func isInternalSecret(name string) bool {
+	return name == authTokenSecret || name == contactsSecret || isTranscript(name)
+}
+
+func (cm *ConfigManager) secretPath(name string) string {
+	return filepath.Join(cm.HomeDir, name+".enc")
+}
+
+func (cm *ConfigManager) secretIndexPath() string {
+	return filepath.Join(cm.HomeDir, "secrets.json")
+}
+
+// updateSecretIndex applies fn to the audit index under a lock.
+func (cm *ConfigManager) updateSecretIndex(fn func(index map[string]*SecretInfo)) error {
+	lock, err := fslock.Acquire(filepath.Join(cm.HomeDir, "secrets.lock"))
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	index, err := cm.loadSecretIndex()
+	if err != nil {
+		return err
+	}
+	fn(index)
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cm.secretIndexPath(), data)
+}
+
+func (cm *ConfigManager) loadSecretIndex() (map[string]*SecretInfo, error) {
+	index := map[string]*SecretInfo{}
+	data, err := os.ReadFile(cm.secretIndexPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", cm.secretIndexPath(), err)
+	}
+	return index, nil
+}
+
+// recordSecretWrite stamps name as created or updated now.
+func (cm *ConfigManager) recordSecretWrite(name string) error {
+	now := time.Now().UTC()
+	return cm.updateSecretIndex(func(index map[string]*SecretInfo) {
+		info, ok := index[name]
+		if !ok {
+			info = &SecretInfo{CreatedAt: now}
+			index[name] = info
+		}
+		info.UpdatedAt = now
+	})
+}
+
+// ListSecrets returns the stored secrets, sorted by name. Secrets written
+// before auditing existed fall back to their file's modification time.
+func (cm *ConfigManager) ListSecrets() ([]SecretInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(cm.HomeDir, "*.enc"))
+	if err != nil {
+		return nil, err
+	}
+	index, err := cm.loadSecretIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []SecretInfo
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".enc")
+		if isInternalSecret(name) {
+			continue
+		}
+
+		info := SecretInfo{}
+		if rec, ok := index[name]; ok {
+			info = *rec
+		} else if fi, err := os.Stat(path); err == nil {
+			info.CreatedAt, info.UpdatedAt = fi.ModTime(), fi.ModTime()
+		}
+		info.Name = name
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// SecretExists reports whether name is stored, without decrypting it.
+func (cm *ConfigManager) SecretExists(name string) bool {
+	_, err := os.Stat(cm.secretPath(name))
+	return err == nil
+}
+
+// RevealSecret decrypts name for display and records when it was revealed.
+func (cm *ConfigManager) RevealSecret(name string) (string, error) {
+	value, err := cm.LoadSecret(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	err = cm.updateSecretIndex(func(index map[string]*SecretInfo) {
+		if info, ok := index[name]; ok {
+			info.RevealedAt = &now
+		} else {
+			index[name] = &SecretInfo{RevealedAt: &now}
+		}
+	})
+	return value, err
+}
+
+// DeleteSecret removes name and its audit record.
+func (cm *ConfigManager) DeleteSecret(name string) error {
+	if err := ValidateSecretName(name); err != nil {
+		return err
+	}
+	err := os.Remove(cm.secretPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	if err != nil {
+		return err
+	}
+	return cm.updateSecretIndex(func(index map[string]*SecretInfo) {
+		delete(index, name)
+	})
+}
+
+// RotateKey re-encrypts every secret under a fresh key from the current
+// source: a new salt and passphrase, or a new random keyring key. The machine
+// and env sources derive their key from outside, so they can't be rotated here.
+func (cm *ConfigManager) RotateKey() (int, error) {
+	cfg, err := cm.loadKeyConfig()
+	if err != nil {
+		return 0, err
+	}
+
+	var src KeySource
+	switch cfg.Source {
+	case KeySourcePassphrase:
+		src, err = cm.NewKeySource(KeySourcePassphrase)
+	case KeySourceKeyring:
+		src = &rotatingKeyringKey{profile: cm.Profile}
+	case KeySourceEnv:
+		return 0, fmt.Errorf("the env key comes from %s; to rotate it, switch to another key source, replace the variable, then switch back to env", masterKeyEnv)
+	default:
+		return 0, fmt.Errorf("the %s key can't be rotated; switch to a passphrase or keyring key source instead", cfg.Source)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return cm.SetKeySource(src)
+}
+
+// RedactSecret masks value for display, keeping only its length.
+func RedactSecret(value string) string {
+	return fmt.Sprintf("%s (%d chars)", strings.Repeat("•", 8), len(value))
+}
@@ -0,0 +1,425 @@
+package sidecar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nathfavour/shadowprism/cli/internal/address"
+	"gopkg.in/yaml.v3"
+)
+
+// SettingsFile overrides where settings are read from, for --config. The
+// SHADOWPRISM_CONFIG environment variable does the same.
+var SettingsFile string
+
+const settingsEnv = "SHADOWPRISM_CONFIG"
+
+// Setting types.
+const (
+	TypeString   = "string"
+	TypeInt      = "int"
+	TypeDuration = "duration"
+	TypeEnum     = "enum"
+	// TypeAddress is a Solana address. Program-derived addresses are
+	// allowed, since vaults and pools are usually owned by a program.
+	TypeAddress = "address"
+)
+
+// SettingSource says where the effective value of a setting came from.
+type SettingSource string
+
+const (
+	SourceDefault SettingSource = "default"
+	SourceFile    SettingSource = "file"
+	SourceEnv     SettingSource = "env"
+)
+
+// Setting describes one key of the settings file.
+type Setting struct {
+	Key     string
+	Type    string
+	Default string
+	Help    string
+	// Choices lists the allowed values of an enum.
+	Choices []string
+	// Env overrides the derived SHADOWPRISM_* environment variable, for
+	// settings the core already reads under another name.
+	Env string
+	// AllowEmpty permits "" for strings; it means "let the core decide".
+	AllowEmpty bool
+}
+
+// Schema lists every supported setting.
+var Schema = []Setting{
+	{Key: "network", Type: TypeEnum, Default: "devnet", Choices: []string{"devnet", "mainnet-beta"},
+		Help: "Solana cluster; picks the public RPC endpoint when rpc.url is unset"},
+	{Key: "rpc.url", Type: TypeString, AllowEmpty: true,
+		Help: "Primary Solana RPC endpoint without an API key; the HELIUS_RPC_URL secret wins over it"},
+	{Key: "rpc.fallback_url", Type: TypeString, AllowEmpty: true,
+		Help: "Keyless RPC endpoint used when the primary fails; the QUICKNODE_RPC_URL secret wins over it"},
+	{Key: "core.port", Type: TypeInt, Default: "42069",
+		Help: "TCP port of the core on platforms without Unix sockets"},
+	{Key: "core.start_timeout", Type: TypeDuration, Default: "10s",
+		Help: "How long to wait for a spawned core to become healthy"},
+	{Key: "shield.strategy", Type: TypeEnum, Default: "privacy_cash", Choices: []string{"privacy_cash", "radr_p2p", "mix_standard"},
+		Help: "Default privacy strategy for shield"},
+	{Key: "swap.from", Type: TypeString, Default: "SOL",
+		Help: "Default token to swap from"},
+	{Key: "swap.to", Type: TypeString, Default: "USDC",
+		Help: "Default token to swap to"},
+	{Key: "task.wait_timeout", Type: TypeDuration, Default: "5m",
+		Help: "Maximum time task --wait blocks"},
+	{Key: "agent.vault", Type: TypeAddress, Default: "PNPVau1t11111111111111111111111111111111111",
+		Help: "PNP vault the payment agent settles into"},
+	{Key: "agent.backend", Type: TypeEnum, Default: "auto", Choices: []string{"auto", "vibeaura", "openai", "stub"},
+		Help: "AI backend; auto uses vibeaura if installed, then agent.base_url"},
+	{Key: "agent.vibeaura_path", Type: TypeString, AllowEmpty: true,
+		Help: "vibeaura binary; empty searches $PATH and ~/.local/bin"},
+	{Key: "agent.base_url", Type: TypeString, AllowEmpty: true,
+		Help: "OpenAI-compatible API root, e.g. http://localhost:11434/v1 for ollama"},
+	{Key: "agent.model", Type: TypeString, AllowEmpty: true,
+		Help: "Model name sent to agent.base_url"},
+	{Key: "agent.history_tokens", Type: TypeInt, Default: "2000",
+		Help: "Approximate tokens of earlier chat turns sent with each message"},
+	{Key: "agent.privacy", Type: TypeEnum, Default: "redact", Choices: []string{"redact", "strict", "off"},
+		Help: "What the AI sees: redact hides addresses, hashes, notes and amounts; strict also refuses secrets"},
+}
+
+// publicRPC is the RPC endpoint used for each network when rpc.url is unset.
+var publicRPC = map[string]string{
+	"devnet":       "https://api.devnet.solana.com",
+	"mainnet-beta": "https://api.mainnet-beta.solana.com",
+}
+
+// LookupSetting finds key in the schema.
+func LookupSetting(key string) (Setting, bool) {
+	for _, s := range Schema {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+// EnvVar is the environment variable that overrides this setting.
+func (s Setting) EnvVar() string {
+	if s.Env != "" {
+		return s.Env
+	}
+	return "SHADOWPRISM_" + strings.ToUpper(strings.ReplaceAll(s.Key, ".", "_"))
+}
+
+// Validate checks value against the setting's type.
+func (s Setting) Validate(value string) error {
+	switch s.Type {
+	case TypeInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer, got %q", s.Key, value)
+		}
+		if strings.HasSuffix(s.Key, ".port") && (n < 1 || n > 65535) {
+			return fmt.Errorf("%s must be a port between 1 and 65535, got %d", s.Key, n)
+		}
+		if n < 0 {
+			return fmt.Errorf("%s must not be negative, got %d", s.Key, n)
+		}
+	case TypeDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%s must be a positive duration like 30s or 5m, got %q", s.Key, value)
+		}
+	case TypeEnum:
+		for _, c := range s.Choices {
+			if value == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of %s, got %q", s.Key, strings.Join(s.Choices, ", "), value)
+	case TypeAddress:
+		if _, err := address.Decode(value); err != nil {
+			return fmt.Errorf("%s: %w", s.Key, err)
+		}
+	default:
+		if value == "" && !s.AllowEmpty {
+			return fmt.Errorf("%s must not be empty", s.Key)
+		}
+	}
+	return nil
+}
+
+// rpcSecrets names the secret that holds the keyed form of each RPC
+// setting. The secret wins when both are set.
+var rpcSecrets = map[string]string{
+	"rpc.url":          "HELIUS_RPC_URL",
+	"rpc.fallback_url": "QUICKNODE_RPC_URL",
+}
+
+// credentialParam matches query parameters that carry a credential, like
+// Helius's api-key.
+var credentialParam = regexp.MustCompile(`(?i)key|token|secret|auth|password`)
+
+// hasCredentials reports whether an endpoint URL carries a user, password
+// or credential query parameter.
+func hasCredentials(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	if u.User != nil {
+		return true
+	}
+	for name := range u.Query() {
+		if credentialParam.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Settings is the settings file layered over environment variables and
+// schema defaults. Command-line flags take precedence over all of these and
+// are applied by the commands themselves.
+type Settings struct {
+	path string
+	file map[string]string
+}
+
+// SettingsPath is the settings file in use: --config, SHADOWPRISM_CONFIG,
+// or config.yaml in the home directory.
+func (cm *ConfigManager) SettingsPath() string {
+	if SettingsFile != "" {
+		return SettingsFile
+	}
+	if path := os.Getenv(settingsEnv); path != "" {
+		return path
+	}
+	return filepath.Join(cm.HomeDir, "config.yaml")
+}
+
+// LoadSettings reads and validates the settings file and any environment
+// overrides. A missing file is not an error; every setting then has its
+// default.
+func (cm *ConfigManager) LoadSettings() (*Settings, error) {
+	for _, s := range Schema {
+		if v, ok := os.LookupEnv(s.EnvVar()); ok {
+			if err := s.Validate(v); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.EnvVar(), err)
+			}
+		}
+	}
+
+	path := cm.SettingsPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Settings{path: path, file: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := ParseSettings(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Settings{path: path, file: file}, nil
+}
+
+// ParseSettings flattens a YAML settings document into dotted keys and
+// validates it against the schema.
+func ParseSettings(data []byte) (map[string]string, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	if err := flattenSettings("", doc, values); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, key := range sortedKeys(values) {
+		s, ok := LookupSetting(key)
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown setting %q", key))
+			continue
+		}
+		if err := s.Validate(values[key]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+func flattenSettings(prefix string, node map[string]any, out map[string]string) error {
+	for k, v := range node {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flattenSettings(key, v, out); err != nil {
+				return err
+			}
+		case []any:
+			return fmt.Errorf("setting %q must be a single value, not a list", key)
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// Path is the file these settings were loaded from and are saved to.
+func (s *Settings) Path() string {
+	return s.path
+}
+
+// Lookup returns the effective value of key and where it came from.
+func (s *Settings) Lookup(key string) (string, SettingSource) {
+	setting, ok := LookupSetting(key)
+	if !ok {
+		return "", SourceDefault
+	}
+	if v, ok := os.LookupEnv(setting.EnvVar()); ok {
+		return v, SourceEnv
+	}
+	if v, ok := s.file[key]; ok {
+		return v, SourceFile
+	}
+	return setting.Default, SourceDefault
+}
+
+// Get returns the effective value of key.
+func (s *Settings) Get(key string) string {
+	v, _ := s.Lookup(key)
+	return v
+}
+
+// Int returns an integer setting. Values are validated on load, so parse
+// errors can't happen for schema keys.
+func (s *Settings) Int(key string) int {
+	n, _ := strconv.Atoi(s.Get(key))
+	return n
+}
+
+// Duration returns a duration setting.
+func (s *Settings) Duration(key string) time.Duration {
+	d, _ := time.ParseDuration(s.Get(key))
+	return d
+}
+
+// Set validates value and stores it in the file settings. Call Save to
+// persist it.
+func (s *Settings) Set(key, value string) error {
+	setting, ok := LookupSetting(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if err := setting.Validate(value); err != nil {
+		return err
+	}
+	// Files written before this check still load; it only stops new keys
+	// landing in plain text.
+	if secret, ok := rpcSecrets[key]; ok && hasCredentials(value) {
+		return fmt.Errorf("%s looks like it contains an API key, which would be stored in plain text; store the URL with `shadowprism config secrets set %s` instead", key, secret)
+	}
+	s.file[key] = value
+	return nil
+}
+
+// Unset removes key from the file settings, restoring its default.
+func (s *Settings) Unset(key string) error {
+	if _, ok := LookupSetting(key); !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	delete(s.file, key)
+	return nil
+}
+
+// Save writes the file settings back as nested YAML. Comments in a
+// hand-edited file are not preserved.
+func (s *Settings) Save() error {
+	doc := map[string]any{}
+	for key, value := range s.file {
+		setting, _ := LookupSetting(key)
+
+		var typed any = value
+		if setting.Type == TypeInt {
+			typed, _ = strconv.Atoi(value)
+		}
+
+		node := doc
+		parts := strings.Split(key, ".")
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = typed
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// RPCURL is the primary RPC endpoint the core should use.
+func (s *Settings) RPCURL() string {
+	if endpoint := s.Get("rpc.url"); endpoint != "" {
+		return endpoint
+	}
+	return publicRPC[s.Get("network")]
+}
+
+// CoreEnv is the environment passed to a core launched with these settings.
+func (s *Settings) CoreEnv() []CoreEnvVar {
+	env := []CoreEnvVar{{Name: "HELIUS_RPC_URL", Value: s.RPCURL(), Source: s.describeSource("rpc.url")}}
+	if _, source := s.Lookup("rpc.url"); source == SourceDefault {
+		env[0].Source = fmt.Sprintf("public %s endpoint", s.Get("network"))
+	}
+	if endpoint := s.Get("rpc.fallback_url"); endpoint != "" {
+		env = append(env, CoreEnvVar{Name: "QUICKNODE_RPC_URL", Value: endpoint, Source: s.describeSource("rpc.fallback_url")})
+	}
+	return env
+}
+
+func (s *Settings) describeSource(key string) string {
+	_, source := s.Lookup(key)
+	if source == SourceEnv {
+		return "environment"
+	}
+	return fmt.Sprintf("setting %s", key)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package sidecar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// transcriptPrefix names sealed chat transcripts. They live beside the other
+// secrets so changing the key source re-encrypts them too.
+const transcriptPrefix = "transcript."
+
+var sessionRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidateSession rejects chat session names that aren't safe as file names.
+func ValidateSession(session string) error {
+	if !sessionRe.MatchString(session) {
+		return fmt.Errorf("invalid session %q: use up to 64 lowercase letters, digits, '_' or '-'", session)
+	}
+	return nil
+}
+
+// LoadTranscript decrypts the transcript of session into v. It reports false
+// without unlocking the secret key when there is none.
+func (cm *ConfigManager) LoadTranscript(session string, v any) (bool, error) {
+	if err := ValidateSession(session); err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(cm.secretPath(transcriptPrefix + session))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	key, err := cm.secretKey()
+	if err != nil {
+		return false, err
+	}
+	plain, err := openSecret(&key, data)
+	if err != nil {
+		return false, fmt.Errorf("transcript %s: %w", session, err)
+	}
+	if err := json.Unmarshal([]byte(plain), v); err != nil {
+		return false, fmt.Errorf("transcript %s: %w", session, err)
+	}
+	return true, nil
+}
+
+// SaveTranscript seals v as the transcript of session, replacing any
+// earlier one.
+func (cm *ConfigManager) SaveTranscript(session string, v any) error {
+	if err := ValidateSession(session); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	key, err := cm.secretKey()
+	if err != nil {
+		return err
+	}
+	sealed, err := sealSecret(&key, string(data))
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cm.secretPath(transcriptPrefix+session), sealed)
+}
+
+// DeleteTranscript removes the transcript of session, if any.
+func (cm *ConfigManager) DeleteTranscript(session string) error {
+	if err := ValidateSession(session); err != nil {
+		return err
+	}
+	err := os.Remove(cm.secretPath(transcriptPrefix + session))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func isTranscript(name string) bool {
+	return strings.HasPrefix(name, transcriptPrefix)
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/nathfavour/shadowprism/cli/api"
 	"github.com/nathfavour/shadowprism/cli/internal/agent"
+	"github.com/nathfavour/shadowprism/cli/internal/amount"
 	"github.com/nathfavour/shadowprism/cli/internal/sidecar"
 )
 
@@ -21,25 +23,45 @@ const (
 	stateSwap
 	statePay
 	stateSettings
+	stateLogs
 )
 
-type statusMsg map[string]interface{}
-type historyMsg []map[string]interface{}
-type shieldResultMsg map[string]interface{}
-type swapResultMsg map[string]interface{}
-type payResultMsg map[string]interface{}
-type hintMsg string
+type statusMsg *api.HealthStatus
+type historyMsg []api.TransactionRecord
+type shieldResultMsg *api.ShieldResponse
+type swapResultMsg *api.SwapResponse
+type payResultMsg *api.PayResponse
+type engineEventMsg sidecar.Event
 type tickMsg time.Time
+type logTickMsg time.Time
+type logLinesMsg []string
+
+// hintMsg is the tip streamed so far; more follows on stream until done.
+type hintMsg struct {
+	text   string
+	stream *agent.Stream
+	cancel context.CancelFunc
+	done   bool
+}
 
 type model struct {
 	state        sessionState
 	cursor       int
 	width        int
 	height       int
+	ctx          context.Context
+	cancel       context.CancelFunc
 	client       *sidecar.CoreClient
-	lastStatus   map[string]interface{}
-	lastHistory  []map[string]interface{}
+	agent        *agent.PrismAgent
+	events       <-chan sidecar.Event
+	lastEvent    *sidecar.Event
+	logs         sidecar.LogSource
+	book         *sidecar.AddressBook
+	logLines     []string
+	lastStatus   *api.HealthStatus
+	lastHistory  []api.TransactionRecord
 	lastHint     string
+	hinting      bool
 	inputs       []textinput.Model
 	focusedInput int
 	isWorking    bool
@@ -49,45 +71,57 @@ type model struct {
 
 var (
 	sidebarStyle = lipgloss.NewStyle().
-		Width(20).
-		Border(lipgloss.NormalBorder(), false, true, false, false).
-		Padding(1, 2).
-		BorderForeground(lipgloss.Color("62"))
+			Width(20).
+			Border(lipgloss.NormalBorder(), false, true, false, false).
+			Padding(1, 2).
+			BorderForeground(lipgloss.Color("62"))
 
 	mainStyle = lipgloss.NewStyle().
-		Padding(1, 2)
+			Padding(1, 2)
 
 	titleStyle = lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("205")).
-		MarginBottom(1)
+			Bold(true).
+			Foreground(lipgloss.Color("205")).
+			MarginBottom(1)
 
 	statusStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("42"))
+			Foreground(lipgloss.Color("42"))
 
 	historyItemStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("240")).
-			Padding(0, 1).
-			MarginBottom(1)
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("240")).
+				Padding(0, 1).
+				MarginBottom(1)
 
 	selectedItemStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("229")).
-			Background(lipgloss.Color("62")).
-			Padding(0, 1)
+				Foreground(lipgloss.Color("229")).
+				Background(lipgloss.Color("62")).
+				Padding(0, 1)
 
 	inactiveItemStyle = lipgloss.NewStyle().
-			Padding(0, 1)
+				Padding(0, 1)
 
 	focusedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 	blurredStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 )
 
-func InitialModel(client *sidecar.CoreClient) model {
+// InitialModel builds the TUI model. Core calls are bound to ctx and are
+// cancelled when the user quits. events may be nil when the TUI is attached
+// to a core it doesn't supervise; logs feeds the engine log pane.
+func InitialModel(ctx context.Context, client *sidecar.CoreClient, pa *agent.PrismAgent, events <-chan sidecar.Event, logs sidecar.LogSource, book *sidecar.AddressBook) model {
+	ctx, cancel := context.WithCancel(ctx)
 	m := model{
 		state:  stateDashboard,
 		cursor: 0,
+		ctx:    ctx,
+		cancel: cancel,
 		client: client,
+		agent:  pa,
+		events: events,
+		logs:   logs,
+		book:   book,
+		// Init starts the first tip.
+		hinting: true,
 	}
 	m.resetInputs()
 	return m
@@ -105,10 +139,10 @@ func (m *model) resetInputs() {
 			t := textinput.New()
 			t.Cursor.Style = focusedStyle
 			if i == 0 {
-				t.Placeholder = "Amount (Lamports)"
+				t.Placeholder = "Amount (e.g. 0.5 SOL or lamports)"
 				t.Focus()
 			} else {
-				t.Placeholder = "Destination Address"
+				t.Placeholder = "Destination Address or @alias"
 			}
 			m.inputs[i] = t
 		}
@@ -119,7 +153,7 @@ func (m *model) resetInputs() {
 			t.Cursor.Style = focusedStyle
 			switch i {
 			case 0:
-				t.Placeholder = "Amount (Lamports)"
+				t.Placeholder = "Amount (e.g. 1.5 SOL or base units)"
 				t.Focus()
 			case 1:
 				t.Placeholder = "From Token (e.g. SOL)"
@@ -134,10 +168,10 @@ func (m *model) resetInputs() {
 			t := textinput.New()
 			t.Cursor.Style = focusedStyle
 			if i == 0 {
-				t.Placeholder = "Amount (Lamports)"
+				t.Placeholder = "Amount (e.g. 0.5 SOL or lamports)"
 				t.Focus()
 			} else {
-				t.Placeholder = "Merchant ID (Address)"
+				t.Placeholder = "Merchant ID (Address or @alias)"
 			}
 			m.inputs[i] = t
 		}
@@ -146,13 +180,31 @@ func (m *model) resetInputs() {
 	}
 }
 
+// fetchHint streams a new tip into the insight line. Quitting cancels m.ctx
+// and with it the stream.
 func (m model) fetchHint() tea.Cmd {
+	ctx, cancel := context.WithTimeout(m.ctx, 10*time.Second)
+	stream := m.agent.TalkStream(ctx, "Provide a random, very short Solana privacy tip.")
+	return nextHint(hintMsg{stream: stream, cancel: cancel})
+}
+
+// nextHint waits for the next piece of the tip after prev.
+func nextHint(prev hintMsg) tea.Cmd {
 	return func() tea.Msg {
-		pa := agent.NewPrismAgent()
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		h, _ := pa.Talk(ctx, "Provide a random, very short Solana privacy tip.")
-		return hintMsg(h)
+		msg := prev
+		if delta, ok := <-prev.stream.Deltas; ok {
+			msg.text += delta
+			return msg
+		}
+		text, err := prev.stream.Wait()
+		prev.cancel()
+		msg.done = true
+		// A failed tip leaves whatever was already shown.
+		msg.text = ""
+		if err == nil {
+			msg.text = text
+		}
+		return msg
 	}
 }
 
@@ -162,18 +214,50 @@ func (m model) Init() tea.Cmd {
 		m.fetchHistory(),
 		m.fetchHint(),
 		m.tick(),
+		m.logTick(),
+		m.waitForEvent(),
 	)
 }
 
+func (m model) waitForEvent() tea.Cmd {
+	if m.events == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		e, ok := <-m.events
+		if !ok {
+			return nil
+		}
+		return engineEventMsg(e)
+	}
+}
+
 func (m model) tick() tea.Cmd {
 	return tea.Tick(time.Second*5, func(t time.Time) tea.Msg {
 		return tickMsg(t)
 	})
 }
 
+func (m model) logTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return logTickMsg(t)
+	})
+}
+
+func (m model) fetchLogs() tea.Cmd {
+	return func() tea.Msg {
+		// Fetch enough to fill a tall terminal; the view trims to fit.
+		lines, err := m.logs.Tail(200)
+		if err != nil {
+			return err
+		}
+		return logLinesMsg(lines)
+	}
+}
+
 func (m model) fetchStatus() tea.Cmd {
 	return func() tea.Msg {
-		status, err := m.client.GetStatus()
+		status, err := m.client.GetStatus(m.ctx)
 		if err != nil {
 			return err
 		}
@@ -183,7 +267,7 @@ func (m model) fetchStatus() tea.Cmd {
 
 func (m model) fetchHistory() tea.Cmd {
 	return func() tea.Msg {
-		history, err := m.client.GetHistory()
+		history, err := m.client.GetHistory(m.ctx, api.HistoryQuery{})
 		if err != nil {
 			return err
 		}
@@ -193,11 +277,20 @@ func (m model) fetchHistory() tea.Cmd {
 
 func (m model) runShield() tea.Cmd {
 	return func() tea.Msg {
-		var amount uint64
-		fmt.Sscanf(m.inputs[0].Value(), "%d", &amount)
-		dest := m.inputs[1].Value()
+		amt, err := amount.Parse(m.inputs[0].Value(), amount.Options{Token: "SOL"})
+		if err != nil {
+			return err
+		}
+		lamports, err := m.client.ResolveAmount(m.ctx, amt)
+		if err != nil {
+			return err
+		}
+		dest, err := m.book.Resolve(m.inputs[1].Value())
+		if err != nil {
+			return err
+		}
 
-		res, err := m.client.Shield(amount, dest, "mix_standard", false)
+		res, err := m.client.Shield(m.ctx, lamports, dest, "mix_standard", false)
 		if err != nil {
 			return err
 		}
@@ -207,12 +300,18 @@ func (m model) runShield() tea.Cmd {
 
 func (m model) runSwap() tea.Cmd {
 	return func() tea.Msg {
-		var amount uint64
-		fmt.Sscanf(m.inputs[0].Value(), "%d", &amount)
 		from := m.inputs[1].Value()
 		to := m.inputs[2].Value()
+		amt, err := amount.Parse(m.inputs[0].Value(), amount.Options{Token: from})
+		if err != nil {
+			return err
+		}
+		units, err := m.client.ResolveAmount(m.ctx, amt)
+		if err != nil {
+			return err
+		}
 
-		res, err := m.client.Swap(amount, from, to)
+		res, err := m.client.Swap(m.ctx, units, from, to)
 		if err != nil {
 			return err
 		}
@@ -222,11 +321,20 @@ func (m model) runSwap() tea.Cmd {
 
 func (m model) runPay() tea.Cmd {
 	return func() tea.Msg {
-		var amount uint64
-		fmt.Sscanf(m.inputs[0].Value(), "%d", &amount)
-		merchant := m.inputs[1].Value()
+		amt, err := amount.Parse(m.inputs[0].Value(), amount.Options{Token: "SOL"})
+		if err != nil {
+			return err
+		}
+		lamports, err := m.client.ResolveAmount(m.ctx, amt)
+		if err != nil {
+			return err
+		}
+		merchant, err := m.book.Resolve(m.inputs[1].Value())
+		if err != nil {
+			return err
+		}
 
-		res, err := m.client.Pay(amount, merchant)
+		res, err := m.client.Pay(m.ctx, lamports, merchant)
 		if err != nil {
 			return err
 		}
@@ -241,34 +349,67 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case historyMsg:
 		m.lastHistory = msg
 	case hintMsg:
-		m.lastHint = string(msg)
+		if msg.text != "" {
+			m.lastHint = msg.text
+		}
+		if msg.done {
+			m.hinting = false
+			return m, nil
+		}
+		return m, nextHint(msg)
+	case engineEventMsg:
+		e := sidecar.Event(msg)
+		m.lastEvent = &e
+		if e.Kind == sidecar.EventStarted {
+			m.err = nil
+			return m, tea.Batch(m.fetchStatus(), m.waitForEvent())
+		}
+		if e.Kind != sidecar.EventRestarting {
+			m.lastStatus = nil
+		}
+		return m, m.waitForEvent()
 	case shieldResultMsg:
 		m.isWorking = false
 		note := ""
-		if n, ok := msg["note"].(string); ok && n != "" {
+		if n := api.Deref(msg.Note); n != "" {
 			note = fmt.Sprintf("\n🔑 Note: %s", n)
 		}
-		m.result = fmt.Sprintf("Shield Success! TX: %s%s", msg["tx_hash"], note)
+		m.result = fmt.Sprintf("Shield Success! TX: %s%s", msg.TxHash, note)
 		return m, m.fetchHistory()
 
 	case swapResultMsg:
 		m.isWorking = false
-		m.result = fmt.Sprintf("Swap Success! TX: %s\nRecieved: %v", msg["tx_hash"], msg["to_amount"])
+		m.result = fmt.Sprintf("Swap Success! TX: %s\nReceived: %s", msg.TxHash, amount.Format(msg.ToAmount, m.inputs[2].Value()))
 		return m, m.fetchHistory()
 
 	case payResultMsg:
 		m.isWorking = false
-		m.result = fmt.Sprintf("Payment Confirmed! TX: %s\nReceipt: %s", msg["tx_hash"], msg["receipt_id"])
+		m.result = fmt.Sprintf("Payment Confirmed! TX: %s\nReceipt: %s", msg.TxHash, msg.ReceiptID)
 		return m, m.fetchHistory()
 
+	case logLinesMsg:
+		m.logLines = msg
+	case logTickMsg:
+		// The log pane is only refreshed while it is on screen.
+		if m.state == stateLogs && m.logs != nil {
+			return m, tea.Batch(m.fetchLogs(), m.logTick())
+		}
+		return m, m.logTick()
 	case tickMsg:
-		return m, tea.Batch(m.fetchStatus(), m.fetchHistory(), m.fetchHint(), m.tick())
+		cmds := []tea.Cmd{m.fetchStatus(), m.fetchHistory(), m.tick()}
+		// A new tip only starts once the last one has finished.
+		if !m.hinting {
+			m.hinting = true
+			cmds = append(cmds, m.fetchHint())
+		}
+		return m, tea.Batch(cmds...)
 	case error:
 		m.err = msg
 		m.isWorking = false
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "q":
+			m.cancel()
 			return m, tea.Quit
 
 		case "tab", "shift+tab", "up", "down":
@@ -278,7 +419,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.cursor--
 					}
 				} else {
-					if m.cursor < 4 { // dashboard, shield, swap, pay, settings
+					if m.cursor < 5 { // dashboard, shield, swap, pay, settings, logs
 						m.cursor++
 					}
 				}
@@ -311,6 +452,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.state == stateDashboard {
 				m.state = sessionState(m.cursor)
 				m.resetInputs()
+				if m.state == stateLogs && m.logs != nil {
+					return m, m.fetchLogs()
+				}
 			} else if (m.state == stateShield || m.state == stateSwap || m.state == statePay) && !m.isWorking {
 				m.isWorking = true
 				m.result = ""
@@ -348,7 +492,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) View() string {
-	sidebarItems := []string{"Dashboard", "Shield SOL", "Private Swap", "Pay Merchant", "Settings"}
+	sidebarItems := []string{"Dashboard", "Shield SOL", "Private Swap", "Pay Merchant", "Settings", "Engine Logs"}
 	var sidebarBuilder strings.Builder
 
 	sidebarBuilder.WriteString(titleStyle.Render("SHADOW PRISM"))
@@ -378,6 +522,8 @@ func (m model) View() string {
 		mainContent = m.renderForm("Pay Merchant", "Enter the payment details below:")
 	case stateSettings:
 		mainContent = m.renderSettings()
+	case stateLogs:
+		mainContent = m.renderLogs()
 	}
 
 	main := mainStyle.Width(m.width - 25).Render(mainContent)
@@ -414,7 +560,11 @@ func (m model) renderForm(title, subtitle string) string {
 func (m model) renderDashboard() string {
 	statusLine := "Core Engine: [OFFLINE]"
 	if m.lastStatus != nil {
-		statusLine = fmt.Sprintf("Core Engine: %s [ONLINE]", statusStyle.Render(fmt.Sprintf("%v", m.lastStatus["engine"])))
+		statusLine = fmt.Sprintf("Core Engine: %s [ONLINE]", statusStyle.Render(m.lastStatus.Engine))
+	}
+
+	if m.lastEvent != nil && m.lastEvent.Kind != sidecar.EventStarted {
+		statusLine += "\n" + focusedStyle.Render("Supervisor: "+m.lastEvent.String())
 	}
 
 	hintLine := ""
@@ -430,7 +580,7 @@ func (m model) renderDashboard() string {
 	} else {
 		for _, tx := range m.lastHistory {
 			item := fmt.Sprintf("ID: %s\nStatus: %s\nProvider: %s\nHash: %s",
-				tx["id"], tx["status"], tx["provider"], tx["tx_hash"])
+				tx.ID, tx.Status, tx.Provider, api.Deref(tx.TxHash))
 			historyBuilder.WriteString(historyItemStyle.Render(item) + "\n")
 		}
 	}
@@ -446,4 +596,29 @@ func (m model) renderDashboard() string {
 
 func (m model) renderSettings() string {
 	return titleStyle.Render("Settings") + "\n\nEndpoint: " + m.client.Socket + "\nCompliance: Enabled"
-}
\ No newline at end of file
+}
+
+func (m model) renderLogs() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Engine Logs") + "\n")
+
+	if len(m.logLines) == 0 {
+		b.WriteString(blurredStyle.Render("No core output yet."))
+		return b.String()
+	}
+
+	// Leave room for the title, padding and the key hint.
+	lines := m.logLines
+	if room := m.height - 8; room > 0 && len(lines) > room {
+		lines = lines[len(lines)-room:]
+	}
+	width := m.width - 30
+	for _, line := range lines {
+		if r := []rune(line); width > 0 && len(r) > width {
+			line = string(r[:width])
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("\n" + blurredStyle.Render("[Esc] for Dashboard"))
+	return b.String()
+}
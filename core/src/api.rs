@@ -1,5 +1,5 @@
 use axum::{
-    extract::{State, Path},
+    extract::{State, Path, Query},
     http::StatusCode,
     Json,
 };
@@ -10,10 +10,20 @@ use crate::adapters::{
     market::MarketOracle,
     SwapProvider, PaymentProvider
 };
-use crate::db::{TransactionStore, TransactionRecord};
+use crate::db::{HistoryFilter, TransactionStore, TransactionRecord};
 use std::sync::{Arc, Mutex};
+use serde::Serialize;
 use serde_json::json;
 
+/// Shield result plus the id of the persisted task, so clients can poll
+/// `/v1/tasks/{id}` until the watchdog settles it.
+#[derive(Debug, Serialize)]
+pub struct ShieldTaskResponse {
+    pub task_id: String,
+    #[serde(flatten)]
+    pub result: ShieldResponse,
+}
+
 pub struct AppState {
     pub rpc: Arc<crate::adapters::rpc::ReliableClient>,
     pub range: crate::adapters::range::RangeClient,
@@ -28,7 +38,7 @@ pub struct AppState {
 pub async fn shield_handler(
     State(state): State<Arc<AppState>>,
     Json(payload): Json<ShieldRequest>,
-) -> Result<Json<ShieldResponse>, (StatusCode, String)> {
+) -> Result<Json<ShieldTaskResponse>, (StatusCode, String)> {
     // 1. Compliance Check
     let risk_score = state.range.check_risk(&payload.destination_addr).await
         .map_err(|e| (StatusCode::INTERNAL_SERVER_ERROR, e))?;
@@ -75,7 +85,7 @@ pub async fn shield_handler(
         }
     }
 
-    Ok(Json(result))
+    Ok(Json(ShieldTaskResponse { task_id, result }))
 }
 
 pub async fn swap_handler(
@@ -109,6 +119,19 @@ pub async fn market_handler(
     }))
 }
 
+pub async fn balance_handler(
+    State(state): State<Arc<AppState>>,
+) -> Result<Json<serde_json::Value>, (StatusCode, String)> {
+    let pubkey = state.keystore.pubkey();
+    let lamports = state.rpc.get_client().get_balance(&pubkey)
+        .map_err(|e| (StatusCode::INTERNAL_SERVER_ERROR, format!("Balance lookup failed: {}", e)))?;
+
+    Ok(Json(json!({
+        "address": pubkey.to_string(),
+        "lamports": lamports
+    })))
+}
+
 pub async fn get_task_handler(
     State(state): State<Arc<AppState>>,
     Path(id): Path<String>,
@@ -132,11 +155,13 @@ pub async fn get_history_handler(
 
     State(state): State<Arc<AppState>>,
 
+    Query(filter): Query<HistoryFilter>,
+
 ) -> Result<Json<Vec<TransactionRecord>>, (StatusCode, String)> {
 
     let db = state.db.lock().unwrap();
 
-    let records = db.list_transactions()
+    let records = db.list_transactions(&filter)
 
         .map_err(|e| (StatusCode::INTERNAL_SERVER_ERROR, e.to_string()))?;
 
@@ -1,4 +1,4 @@
-use rusqlite::{params, Connection, Result};
+use rusqlite::{params, params_from_iter, Connection, Result, Row, ToSql};
 use serde::{Deserialize, Serialize};
 use uuid::Uuid;
 use chrono::{DateTime, Utc};
@@ -18,6 +18,25 @@ pub struct TransactionRecord {
     pub created_at: DateTime<Utc>,
 }
 
+/// Rows returned by `list_transactions` when the caller sets no limit.
+pub const DEFAULT_HISTORY_LIMIT: u32 = 50;
+/// Upper bound on one page of history, so a client can't pull the whole
+/// table in one request.
+pub const MAX_HISTORY_LIMIT: u32 = 1000;
+
+/// Narrows `list_transactions`. Unset fields match every row; `until` is
+/// exclusive. Status and provider compare case-insensitively.
+#[derive(Debug, Default, Deserialize)]
+pub struct HistoryFilter {
+    pub status: Option<String>,
+    pub provider: Option<String>,
+    pub destination: Option<String>,
+    pub since: Option<DateTime<Utc>>,
+    pub until: Option<DateTime<Utc>>,
+    pub limit: Option<u32>,
+    pub offset: Option<u32>,
+}
+
 pub struct TransactionStore {
     conn: Connection,
 }
@@ -77,42 +96,46 @@ impl TransactionStore {
         self.conn.query_row(
             "SELECT id, amount_lamports, destination, status, tx_hash, provider, note, created_at FROM transactions WHERE id = ?1",
             params![id],
-            |row| {
-                let amount_i64: i64 = row.get(1)?;
-                let created_at_str: String = row.get(7)?;
-                Ok(TransactionRecord {
-                    id: row.get(0)?,
-                    amount_lamports: amount_i64 as u64,
-                    destination: row.get(2)?,
-                    status: row.get(3)?,
-                    tx_hash: row.get(4)?,
-                    provider: row.get(5)?,
-                    note: row.get(6)?,
-                    created_at: DateTime::parse_from_rfc3339(&created_at_str).unwrap().with_timezone(&Utc),
-                })
-            },
+            record_from_row,
         )
     }
 
-    pub fn list_transactions(&self) -> Result<Vec<TransactionRecord>> {
-        let mut stmt = self.conn.prepare(
-            "SELECT id, amount_lamports, destination, status, tx_hash, provider, note, created_at FROM transactions ORDER BY created_at DESC LIMIT 50"
-        )?;
-        
-        let rows = stmt.query_map([], |row| {
-            let amount_i64: i64 = row.get(1)?;
-            let created_at_str: String = row.get(7)?;
-            Ok(TransactionRecord {
-                id: row.get(0)?,
-                amount_lamports: amount_i64 as u64,
-                destination: row.get(2)?,
-                status: row.get(3)?,
-                tx_hash: row.get(4)?,
-                provider: row.get(5)?,
-                note: row.get(6)?,
-                created_at: DateTime::parse_from_rfc3339(&created_at_str).unwrap().with_timezone(&Utc),
-            })
-        })?;
+    /// Lists transactions newest first. Timestamps are stored as RFC 3339
+    /// in UTC, so the date range compares them as strings.
+    pub fn list_transactions(&self, filter: &HistoryFilter) -> Result<Vec<TransactionRecord>> {
+        let mut sql = String::from(
+            "SELECT id, amount_lamports, destination, status, tx_hash, provider, note, created_at FROM transactions WHERE 1 = 1"
+        );
+        let mut args: Vec<Box<dyn ToSql>> = Vec::new();
+
+        if let Some(ref status) = filter.status {
+            sql.push_str(" AND status = ? COLLATE NOCASE");
+            args.push(Box::new(status.clone()));
+        }
+        if let Some(ref provider) = filter.provider {
+            sql.push_str(" AND provider = ? COLLATE NOCASE");
+            args.push(Box::new(provider.clone()));
+        }
+        if let Some(ref destination) = filter.destination {
+            sql.push_str(" AND destination = ?");
+            args.push(Box::new(destination.clone()));
+        }
+        if let Some(since) = filter.since {
+            sql.push_str(" AND created_at >= ?");
+            args.push(Box::new(since.to_rfc3339()));
+        }
+        if let Some(until) = filter.until {
+            sql.push_str(" AND created_at < ?");
+            args.push(Box::new(until.to_rfc3339()));
+        }
+
+        let limit = filter.limit.unwrap_or(DEFAULT_HISTORY_LIMIT).min(MAX_HISTORY_LIMIT);
+        sql.push_str(" ORDER BY created_at DESC LIMIT ? OFFSET ?");
+        args.push(Box::new(limit as i64));
+        args.push(Box::new(filter.offset.unwrap_or(0) as i64));
+
+        let mut stmt = self.conn.prepare(&sql)?;
+        let rows = stmt.query_map(params_from_iter(args.iter()), record_from_row)?;
 
         let mut results = Vec::new();
         for row in rows {
@@ -121,3 +144,18 @@ impl TransactionStore {
         Ok(results)
     }
 }
+
+fn record_from_row(row: &Row) -> Result<TransactionRecord> {
+    let amount_i64: i64 = row.get(1)?;
+    let created_at_str: String = row.get(7)?;
+    Ok(TransactionRecord {
+        id: row.get(0)?,
+        amount_lamports: amount_i64 as u64,
+        destination: row.get(2)?,
+        status: row.get(3)?,
+        tx_hash: row.get(4)?,
+        provider: row.get(5)?,
+        note: row.get(6)?,
+        created_at: DateTime::parse_from_rfc3339(&created_at_str).unwrap().with_timezone(&Utc),
+    })
+}
@@ -1,7 +1,7 @@
 use std::sync::{Arc, Mutex};
 use std::time::Duration;
 use tokio::time::sleep;
-use crate::db::TransactionStore;
+use crate::db::{HistoryFilter, TransactionStore};
 use solana_client::rpc_client::RpcClient;
 use solana_sdk::signature::Signature;
 use std::str::FromStr;
@@ -32,7 +32,7 @@ impl Watchdog {
         let pending_tasks = {
             let store = self.store.lock().unwrap();
             // We'll reuse list_transactions but filter locally for this example
-            store.list_transactions().unwrap_or_default()
+            store.list_transactions(&HistoryFilter::default()).unwrap_or_default()
         };
 
         for task in pending_tasks {
@@ -10,7 +10,7 @@ use axum::{
 };
 use std::sync::{Arc, Mutex};
 use rand::Rng;
-use crate::api::{AppState, shield_handler, get_task_handler, get_history_handler, swap_handler, pay_handler, market_handler};
+use crate::api::{AppState, shield_handler, get_task_handler, get_history_handler, swap_handler, pay_handler, market_handler, balance_handler};
 use crate::adapters::{
     privacy_cash::PrivacyCashAdapter, 
     radr::RadrAdapter, 
@@ -29,9 +29,16 @@ use tokio::net::UnixListener;
 async fn main() {
     tracing_subscriber::fmt::init();
 
-    // Resolve ~/.shadowprism/
-    let mut data_dir = home::home_dir().expect("Could not find home directory");
-    data_dir.push(".shadowprism");
+    // Resolve the data directory: the CLI points each profile at its own,
+    // falling back to ~/.shadowprism/
+    let data_dir = match std::env::var_os("SHADOWPRISM_DATA_DIR") {
+        Some(dir) => std::path::PathBuf::from(dir),
+        None => {
+            let mut dir = home::home_dir().expect("Could not find home directory");
+            dir.push(".shadowprism");
+            dir
+        }
+    };
     if !data_dir.exists() {
         std::fs::create_dir_all(&data_dir).expect("Could not create .shadowprism directory");
     }
@@ -100,6 +107,7 @@ async fn main() {
         .route("/v1/swap", post(swap_handler))
         .route("/v1/pay", post(pay_handler))
         .route("/v1/market", get(market_handler))
+        .route("/v1/balance", get(balance_handler))
         .route("/v1/tasks/{id}", get(get_task_handler))
         .route("/v1/history", get(get_history_handler))
         .layer(axum::middleware::from_fn(middleware::auth_validator))
@@ -14,8 +14,12 @@ pub async fn auth_validator(
         .get("Authorization")
         .and_then(|h| h.to_str().ok());
 
-    let expected_token = env::var("SHADOWPRISM_AUTH_TOKEN")
-        .unwrap_or_else(|_| "dev-token-123".to_string());
+    // The launcher generates a fresh token per run; without one, refuse
+    // everything rather than fall back to a guessable default.
+    let expected_token = match env::var("SHADOWPRISM_AUTH_TOKEN") {
+        Ok(token) if !token.is_empty() => token,
+        _ => return Err(StatusCode::UNAUTHORIZED),
+    };
     
     let expected_auth = format!("Bearer {}", expected_token);
 
@@ -42,7 +42,7 @@ if [ -d "core" ]; then
     cd core
     cargo build --release
     # Remove existing binary to prevent 'text file busy' errors
-    rm -f "$PRISM_HOME/bin/shadowprism-core"
+    rm -f "$PRISM_HOME/bin/shadowprism-core" "$PRISM_HOME/bin/shadowprism-core.sha256"
     cp target/release/shadowprism-core "$PRISM_HOME/bin/"
     
     # Prepare for Go embedding